@@ -1,8 +1,18 @@
 package env
 
+import "matching-engine/pkg/engine"
+
 type SystemConfig struct {
+	// Daily trading schedules, each to be passed to Engine.Schedule once its
+	// symbol is listed. The process builds no Engine yet, so nothing runs
+	// them.
+	Sessions []engine.SessionConfig
 }
 
 func defaultConfig() *SystemConfig {
 	return &SystemConfig{}
 }
+
+func Config() SystemConfig {
+	return *config
+}
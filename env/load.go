@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
@@ -39,7 +40,13 @@ func LoadConfig(configFile string) {
 		}
 	}
 
-	if err := viper.Unmarshal(config); err != nil {
+	// Session states and times of day are written as text.
+	hook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.TextUnmarshallerHookFunc(),
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	))
+	if err := viper.Unmarshal(config, hook); err != nil {
 		panic(err)
 	}
 }
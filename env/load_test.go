@@ -0,0 +1,49 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"matching-engine/pkg/orderbook"
+)
+
+func TestLoadSessionSchedule(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+sessions:
+  - symbol: BTCUSDT
+    location: Europe/Berlin
+    schedule:
+      - at: "08:55"
+        state: PreOpen
+      - at: "09:00"
+        state: Continuous
+      - at: "17:30:30"
+        state: PostClose
+`
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	LoadConfig(file)
+
+	sessions := Config().Sessions
+	if len(sessions) != 1 || sessions[0].Symbol != "BTCUSDT" || sessions[0].Location != "Europe/Berlin" {
+		t.Fatalf("Sessions = %+v", sessions)
+	}
+	want := []orderbook.ScheduledTransition{
+		{At: orderbook.TimeOfDay(8*time.Hour + 55*time.Minute), State: orderbook.SessionPreOpen},
+		{At: orderbook.TimeOfDay(9 * time.Hour), State: orderbook.SessionContinuous},
+		{At: orderbook.TimeOfDay(17*time.Hour + 30*time.Minute + 30*time.Second), State: orderbook.SessionPostClose},
+	}
+	schedule := sessions[0].Schedule
+	if len(schedule) != len(want) {
+		t.Fatalf("Schedule = %+v, want %+v", schedule, want)
+	}
+	for i := range want {
+		if schedule[i] != want[i] {
+			t.Errorf("Schedule[%d] = %s %s, want %s %s", i, schedule[i].At, schedule[i].State, want[i].At, want[i].State)
+		}
+	}
+}
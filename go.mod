@@ -5,6 +5,8 @@ go 1.22.0
 require github.com/emirpasic/gods/v2 v2.0.0-alpha
 
 require (
+	github.com/go-viper/mapstructure/v2 v2.2.1
+	github.com/gorilla/schema v1.4.1
 	github.com/gorilla/websocket v1.5.3
 	github.com/spf13/viper v1.20.1
 	go.uber.org/zap v1.27.0
@@ -13,8 +15,6 @@ require (
 
 require (
 	github.com/fsnotify/fsnotify v1.8.0 // indirect
-	github.com/go-viper/mapstructure/v2 v2.2.1 // indirect
-	github.com/gorilla/schema v1.4.1 // indirect
 	github.com/pelletier/go-toml/v2 v2.2.3 // indirect
 	github.com/sagikazarmark/locafero v0.7.0 // indirect
 	github.com/sourcegraph/conc v0.3.0 // indirect
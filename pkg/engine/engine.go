@@ -0,0 +1,273 @@
+package engine
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"matching-engine/pkg/orderbook"
+)
+
+type market struct {
+	instrument Instrument
+	book       *orderbook.OrderBook
+	listed     context.Context // Done once the symbol is delisted
+	delist     context.CancelFunc
+}
+
+// Engine owns one order book per listed instrument and routes commands to it
+// by symbol. Books lock themselves, so the engine lock only guards the set of
+// listed symbols.
+type Engine struct {
+	sync.RWMutex
+	markets map[string]*market
+	ledger  *Ledger
+}
+
+type Option func(*Engine)
+
+// WithLedger checks every order against the accounts' balances in ledger
+// before it reaches a book, and settles fills, cancels and expiries in it.
+// Orders are rejected with ErrInsufficientBalance when their account cannot
+// fund them.
+func WithLedger(ledger *Ledger) Option {
+	return func(e *Engine) {
+		e.ledger = ledger
+	}
+}
+
+func NewEngine(opts ...Option) *Engine {
+	e := &Engine{
+		markets: make(map[string]*market),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// List registers an instrument and opens an empty book for it. Options are
+// passed to the book after the instrument's tick and lot size.
+func (e *Engine) List(instrument Instrument, opts ...orderbook.Option) error {
+	if err := instrument.validate(); err != nil {
+		return err
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	if _, exists := e.markets[instrument.Symbol]; exists {
+		return ErrSymbolExists
+	}
+
+	opts = append([]orderbook.Option{
+		orderbook.WithTickSize(instrument.TickSize),
+		orderbook.WithLotSize(instrument.LotSize),
+	}, opts...)
+	book := orderbook.NewOrderBook(opts...)
+	if e.ledger != nil {
+		ledger := e.ledger
+		book.Subscribe(orderbook.ListenerFunc(func(event orderbook.Event) {
+			ledger.apply(instrument, event)
+		}))
+	}
+	listed, delist := context.WithCancel(context.Background())
+	e.markets[instrument.Symbol] = &market{
+		instrument: instrument,
+		book:       book,
+		listed:     listed,
+		delist:     delist,
+	}
+	return nil
+}
+
+// Delist removes a symbol and returns the orders that were still in its book.
+func (e *Engine) Delist(symbol string) ([]orderbook.Order, error) {
+	e.Lock()
+	m, found := e.markets[symbol]
+	delete(e.markets, symbol)
+	e.Unlock()
+
+	if !found {
+		return nil, ErrUnknownSymbol
+	}
+	m.delist()
+	return m.book.CancelAll(), nil
+}
+
+// Instruments returns the listed instruments sorted by symbol.
+func (e *Engine) Instruments() []Instrument {
+	e.RLock()
+	defer e.RUnlock()
+
+	instruments := make([]Instrument, 0, len(e.markets))
+	for _, m := range e.markets {
+		instruments = append(instruments, m.instrument)
+	}
+	sort.Slice(instruments, func(i, j int) bool {
+		return instruments[i].Symbol < instruments[j].Symbol
+	})
+	return instruments
+}
+
+func (e *Engine) Instrument(symbol string) (Instrument, error) {
+	m, err := e.market(symbol)
+	if err != nil {
+		return Instrument{}, err
+	}
+	return m.instrument, nil
+}
+
+// Book returns the order book of a symbol for direct access.
+func (e *Engine) Book(symbol string) (*orderbook.OrderBook, error) {
+	m, err := e.market(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return m.book, nil
+}
+
+func (e *Engine) market(symbol string) (*market, error) {
+	e.RLock()
+	defer e.RUnlock()
+
+	m, found := e.markets[symbol]
+	if !found {
+		return nil, ErrUnknownSymbol
+	}
+	return m, nil
+}
+
+// PlaceOrder checks the order against the instrument's trading rules and the
+// account's balance, and places it in the symbol's book.
+func (e *Engine) PlaceOrder(symbol string, order orderbook.Order) (orderbook.ExecutionReport, error) {
+	m, err := e.market(symbol)
+	if err != nil {
+		return orderbook.ExecutionReport{OrderID: order.ID, Status: orderbook.StatusRejected}, err
+	}
+	if err := m.instrument.checkOrder(&order, m.referencePrice(order.Side)); err != nil {
+		return orderbook.ExecutionReport{OrderID: order.ID, Status: orderbook.StatusRejected}, err
+	}
+	if e.ledger != nil {
+		if err := e.ledger.reserve(m.instrument, &order); err != nil {
+			return orderbook.ExecutionReport{OrderID: order.ID, Status: orderbook.StatusRejected}, err
+		}
+	}
+	return m.book.PlaceOrder(order)
+}
+
+// referencePrice is the best price a market order on side would trade at.
+func (m *market) referencePrice(side orderbook.OrderSide) orderbook.Decimal {
+	best, _, _ := m.book.GetBestAsk()
+	if side == orderbook.Ask {
+		best, _, _ = m.book.GetBestBid()
+	}
+	return best
+}
+
+// PlaceOrderList checks every member of an order list, such as an OCO pair,
+// against the instrument's trading rules and places the list in the symbol's
+// book. A member that breaks a rule rejects the whole list.
+func (e *Engine) PlaceOrderList(symbol string, orders orderbook.OrderList) ([]orderbook.ExecutionReport, error) {
+	rejected := func(err error) ([]orderbook.ExecutionReport, error) {
+		reports := make([]orderbook.ExecutionReport, len(orders.Orders))
+		for i, order := range orders.Orders {
+			reports[i] = orderbook.ExecutionReport{OrderID: order.ID, Status: orderbook.StatusRejected}
+		}
+		return reports, err
+	}
+
+	m, err := e.market(symbol)
+	if err != nil {
+		return rejected(err)
+	}
+	for i := range orders.Orders {
+		order := &orders.Orders[i]
+		if err := m.instrument.checkOrder(order, m.referencePrice(order.Side)); err != nil {
+			return rejected(err)
+		}
+	}
+	if e.ledger != nil {
+		if err := e.ledger.reserveList(m.instrument, orders.Orders); err != nil {
+			return rejected(err)
+		}
+	}
+	return m.book.PlaceOrderList(orders)
+}
+
+func (e *Engine) CancelOrder(symbol, orderID string) (orderbook.Order, error) {
+	m, err := e.market(symbol)
+	if err != nil {
+		return orderbook.Order{}, err
+	}
+	return m.book.CancelOrder(orderID)
+}
+
+func (e *Engine) AmendOrder(symbol, orderID string, newPrice, newQty orderbook.Decimal) (orderbook.ExecutionReport, error) {
+	m, err := e.market(symbol)
+	if err != nil {
+		return orderbook.ExecutionReport{OrderID: orderID, Status: orderbook.StatusRejected}, err
+	}
+	// An order the book no longer holds is refused by the book below.
+	order, _ := m.book.GetOrder(orderID)
+	if err := m.instrument.checkAmend(order, newPrice, newQty); err != nil {
+		return orderbook.ExecutionReport{OrderID: orderID, Status: orderbook.StatusRejected}, err
+	}
+	if e.ledger == nil {
+		return m.book.AmendOrder(orderID, newPrice, newQty)
+	}
+
+	if err := e.ledger.reserveAmend(m.instrument.Symbol, orderID, newPrice, newQty); err != nil {
+		return orderbook.ExecutionReport{OrderID: orderID, Status: orderbook.StatusRejected}, err
+	}
+	report, err := m.book.AmendOrder(orderID, newPrice, newQty)
+	if err != nil {
+		e.ledger.settle(m.instrument.Symbol, orderID)
+	}
+	return report, err
+}
+
+func (e *Engine) GetOrder(symbol, orderID string) (orderbook.Order, error) {
+	m, err := e.market(symbol)
+	if err != nil {
+		return orderbook.Order{}, err
+	}
+	order, found := m.book.GetOrder(orderID)
+	if !found {
+		return orderbook.Order{}, orderbook.ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (e *Engine) Depth(symbol string, n int) (orderbook.Depth, error) {
+	m, err := e.market(symbol)
+	if err != nil {
+		return orderbook.Depth{}, err
+	}
+	return m.book.Depth(n), nil
+}
+
+func (e *Engine) Snapshot(symbol string) (orderbook.Snapshot, error) {
+	m, err := e.market(symbol)
+	if err != nil {
+		return orderbook.Snapshot{}, err
+	}
+	return m.book.Snapshot(), nil
+}
+
+func (e *Engine) Session(symbol string) (orderbook.SessionState, error) {
+	m, err := e.market(symbol)
+	if err != nil {
+		return 0, err
+	}
+	return m.book.Session(), nil
+}
+
+// SetSession moves a symbol to another trading phase, e.g. to halt it by hand.
+func (e *Engine) SetSession(symbol string, state orderbook.SessionState) (orderbook.AuctionResult, error) {
+	m, err := e.market(symbol)
+	if err != nil {
+		return orderbook.AuctionResult{}, err
+	}
+	return m.book.SetSession(state)
+}
@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"matching-engine/pkg/orderbook"
+)
+
+func btcusdt() Instrument {
+	return Instrument{
+		Symbol:     "BTCUSDT",
+		BaseAsset:  "BTC",
+		QuoteAsset: "USDT",
+		TickSize:   orderbook.MustDecimal("0.01"),
+		LotSize:    orderbook.MustDecimal("0.00001"),
+	}
+}
+
+func TestEngineListing(t *testing.T) {
+	e := NewEngine()
+
+	if err := e.List(btcusdt()); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if err := e.List(btcusdt()); !errors.Is(err, ErrSymbolExists) {
+		t.Errorf("List() twice error = %v, want ErrSymbolExists", err)
+	}
+	if err := e.List(Instrument{Symbol: "ETHUSDT"}); !errors.Is(err, ErrInvalidInstrument) {
+		t.Errorf("List() incomplete error = %v, want ErrInvalidInstrument", err)
+	}
+
+	eth := btcusdt()
+	eth.Symbol, eth.BaseAsset = "ETHUSDT", "ETH"
+	if err := e.List(eth); err != nil {
+		t.Fatalf("List(ETHUSDT) error = %v", err)
+	}
+
+	instruments := e.Instruments()
+	if len(instruments) != 2 || instruments[0].Symbol != "BTCUSDT" || instruments[1].Symbol != "ETHUSDT" {
+		t.Errorf("Instruments() = %+v", instruments)
+	}
+
+	if _, err := e.PlaceOrder("ETHUSDT", orderbook.Order{ID: "e1", Side: orderbook.Bid, Price: orderbook.MustDecimal("2500"), Quantity: orderbook.MustDecimal("1")}); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+
+	canceled, err := e.Delist("ETHUSDT")
+	if err != nil {
+		t.Fatalf("Delist() error = %v", err)
+	}
+	if len(canceled) != 1 || canceled[0].ID != "e1" {
+		t.Errorf("Delist() = %+v, want e1 canceled", canceled)
+	}
+	if _, err := e.Book("ETHUSDT"); !errors.Is(err, ErrUnknownSymbol) {
+		t.Errorf("Book() after delist error = %v, want ErrUnknownSymbol", err)
+	}
+}
+
+func TestEngineRouting(t *testing.T) {
+	e := NewEngine()
+	if err := e.List(btcusdt()); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	if _, err := e.PlaceOrder("BTCUSDT", orderbook.Order{ID: "a1", Side: orderbook.Ask, Price: orderbook.MustDecimal("111351.12"), Quantity: orderbook.MustDecimal("0.0001")}); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	if _, err := e.PlaceOrder("DOGEUSDT", orderbook.Order{ID: "d1"}); !errors.Is(err, ErrUnknownSymbol) {
+		t.Errorf("PlaceOrder(DOGEUSDT) error = %v, want ErrUnknownSymbol", err)
+	}
+
+	report, err := e.PlaceOrder("BTCUSDT", orderbook.Order{ID: "b1", Side: orderbook.Bid, Price: orderbook.MustDecimal("111351.12"), Quantity: orderbook.MustDecimal("0.0001")})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	if report.Status != orderbook.StatusFilled {
+		t.Errorf("Status = %v, want Filled", report.Status)
+	}
+
+	if _, err := e.GetOrder("BTCUSDT", "a1"); !errors.Is(err, orderbook.ErrOrderNotFound) {
+		t.Errorf("GetOrder() error = %v, want ErrOrderNotFound", err)
+	}
+
+	if _, err := e.SetSession("BTCUSDT", orderbook.SessionHalted); err != nil {
+		t.Fatalf("SetSession() error = %v", err)
+	}
+	if state, _ := e.Session("BTCUSDT"); state != orderbook.SessionHalted {
+		t.Errorf("Session() = %s, want Halted", state)
+	}
+	if _, err := e.PlaceOrder("BTCUSDT", orderbook.Order{ID: "b2", Side: orderbook.Bid, Price: orderbook.MustDecimal("111351.12"), Quantity: orderbook.MustDecimal("0.0001")}); !errors.Is(err, orderbook.ErrTradingHalted) {
+		t.Errorf("PlaceOrder() while halted error = %v, want ErrTradingHalted", err)
+	}
+}
+
+func TestEngineSchedule(t *testing.T) {
+	e := NewEngine()
+	if err := e.List(btcusdt()); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	halt := []orderbook.ScheduledTransition{{State: orderbook.SessionHalted}}
+	tests := []struct {
+		name    string
+		session SessionConfig
+		want    error
+	}{
+		{"unknown symbol", SessionConfig{Symbol: "ETHUSDT", Schedule: halt}, ErrUnknownSymbol},
+		{"unknown time zone", SessionConfig{Symbol: "BTCUSDT", Location: "Mars/Olympus", Schedule: halt}, ErrInvalidSchedule},
+		{"empty schedule", SessionConfig{Symbol: "BTCUSDT"}, ErrInvalidSchedule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := e.Schedule(ctx, tt.session, nil); !errors.Is(err, tt.want) {
+				t.Errorf("Schedule() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// Halt the book two seconds from now.
+	now := time.Now().UTC()
+	at := now.Truncate(time.Second).Add(2 * time.Second)
+	halt[0].At = orderbook.TimeOfDay(at.Sub(at.Truncate(24 * time.Hour)))
+	if err := e.Schedule(ctx, SessionConfig{Symbol: "BTCUSDT", Location: "UTC", Schedule: halt}, nil); err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+
+	for deadline := at.Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if state, _ := e.Session("BTCUSDT"); state == orderbook.SessionHalted {
+			return
+		}
+	}
+	t.Errorf("Session() did not reach Halted by %s", at)
+}
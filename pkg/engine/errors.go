@@ -0,0 +1,14 @@
+package engine
+
+import "errors"
+
+var (
+	ErrInvalidInstrument = errors.New("engine: instrument needs symbol, assets, and positive tick and lot size")
+	ErrSymbolExists      = errors.New("engine: symbol already listed")
+	ErrUnknownSymbol     = errors.New("engine: unknown symbol")
+	ErrInvalidSchedule   = errors.New("engine: session schedule needs transitions and a known time zone")
+
+	ErrInvalidAmount       = errors.New("engine: amount must be positive")
+	ErrInsufficientBalance = errors.New("engine: insufficient balance")
+	ErrUnfundedOrder       = errors.New("engine: buy orders without a limit price need a protection price, or a cap if pegged")
+)
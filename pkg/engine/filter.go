@@ -0,0 +1,133 @@
+package engine
+
+import "matching-engine/pkg/orderbook"
+
+// FilterError is returned when an order breaks a trading rule of its
+// instrument. Code and message follow Binance's filter failures, which
+// clients already handle.
+type FilterError struct {
+	Code   int
+	Filter string
+}
+
+func (e *FilterError) Error() string {
+	return "Filter failure: " + e.Filter
+}
+
+const filterFailureCode = -1013
+
+var (
+	ErrPriceFilter   = &FilterError{Code: filterFailureCode, Filter: "PRICE_FILTER"}
+	ErrLotSize       = &FilterError{Code: filterFailureCode, Filter: "LOT_SIZE"}
+	ErrMarketLotSize = &FilterError{Code: filterFailureCode, Filter: "MARKET_LOT_SIZE"}
+	ErrMinNotional   = &FilterError{Code: filterFailureCode, Filter: "MIN_NOTIONAL"}
+)
+
+// checkOrder applies the instrument's trading rules to an order before it
+// reaches the book. reference is the price used for the notional check of
+// orders without a limit price; a zero reference skips it.
+func (i Instrument) checkOrder(order *orderbook.Order, reference orderbook.Decimal) error {
+	isMarket := order.Type == orderbook.Market || order.Type == orderbook.Stop
+	// A pegged order takes its price from the book, which keeps it on the
+	// tick grid as long as the offset and cap are.
+	pegged := order.Peg != orderbook.PegNone
+
+	if pegged {
+		if !order.PegOffset.IsMultipleOf(i.TickSize) {
+			return ErrPriceFilter
+		}
+		if !order.PegCap.IsZero() && !i.validPrice(order.PegCap) {
+			return ErrPriceFilter
+		}
+	} else if !isMarket && !i.validPrice(order.Price) {
+		return ErrPriceFilter
+	}
+	if order.Type == orderbook.Stop || order.Type == orderbook.StopLimit {
+		// A trailing stop may leave its trigger to the book.
+		trailing := order.TrailingAmount.IsPositive() || order.TrailingPercent.IsPositive()
+		if !(trailing && order.StopPrice.IsZero()) && !i.validPrice(order.StopPrice) {
+			return ErrPriceFilter
+		}
+		if order.TrailingAmount.IsPositive() && !order.TrailingAmount.IsMultipleOf(i.TickSize) {
+			return ErrPriceFilter
+		}
+	}
+	if !order.ProtectionPrice.IsZero() && !i.validPrice(order.ProtectionPrice) {
+		return ErrPriceFilter
+	}
+
+	if !i.validQuantity(order.Quantity) {
+		if isMarket {
+			return ErrMarketLotSize
+		}
+		return ErrLotSize
+	}
+	if !order.DisplayQuantity.IsZero() && !order.DisplayQuantity.IsMultipleOf(i.LotSize) {
+		return ErrLotSize
+	}
+
+	price := order.Price
+	if isMarket || pegged {
+		price = reference
+	}
+	return i.checkNotional(price, order.Quantity)
+}
+
+// checkAmend applies the rules to the new values of an amend of order. Zero
+// values are left unchanged by the book and are not checked, but the notional
+// is checked with the order's current price or quantity in their place.
+func (i Instrument) checkAmend(order orderbook.Order, price, quantity orderbook.Decimal) error {
+	if !price.IsZero() && !i.validPrice(price) {
+		return ErrPriceFilter
+	}
+	if !quantity.IsZero() && !i.validQuantity(quantity) {
+		return ErrLotSize
+	}
+	if price.IsZero() {
+		price = order.Price
+	}
+	if quantity.IsZero() {
+		quantity = order.Quantity
+	}
+	return i.checkNotional(price, quantity)
+}
+
+func (i Instrument) validPrice(price orderbook.Decimal) bool {
+	if !price.IsPositive() || !price.IsMultipleOf(i.TickSize) {
+		return false
+	}
+	if !i.MinPrice.IsZero() && price.LessThan(i.MinPrice) {
+		return false
+	}
+	if !i.MaxPrice.IsZero() && price.GreaterThan(i.MaxPrice) {
+		return false
+	}
+	return true
+}
+
+func (i Instrument) validQuantity(quantity orderbook.Decimal) bool {
+	if !quantity.IsPositive() || !quantity.IsMultipleOf(i.LotSize) {
+		return false
+	}
+	if !i.MinQuantity.IsZero() && quantity.LessThan(i.MinQuantity) {
+		return false
+	}
+	if !i.MaxQuantity.IsZero() && quantity.GreaterThan(i.MaxQuantity) {
+		return false
+	}
+	return true
+}
+
+func (i Instrument) checkNotional(price, quantity orderbook.Decimal) error {
+	if i.MinNotional.IsZero() || price.IsZero() {
+		return nil
+	}
+	notional, err := price.Mul(quantity)
+	if err != nil {
+		return err
+	}
+	if notional.LessThan(i.MinNotional) {
+		return ErrMinNotional
+	}
+	return nil
+}
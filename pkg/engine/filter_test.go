@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"matching-engine/pkg/orderbook"
+)
+
+func TestTradingRules(t *testing.T) {
+	instrument := btcusdt()
+	instrument.MinPrice = orderbook.MustDecimal("1")
+	instrument.MaxPrice = orderbook.MustDecimal("1000000")
+	instrument.MinQuantity = orderbook.MustDecimal("0.0001")
+	instrument.MaxQuantity = orderbook.MustDecimal("100")
+	instrument.MinNotional = orderbook.MustDecimal("5")
+
+	e := NewEngine()
+	if err := e.List(instrument); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if _, err := e.PlaceOrder("BTCUSDT", orderbook.Order{ID: "a1", Side: orderbook.Ask, Price: orderbook.MustDecimal("40000"), Quantity: orderbook.MustDecimal("1")}); err != nil {
+		t.Fatalf("PlaceOrder(a1) error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		order orderbook.Order
+		want  error
+	}{
+		{"valid", orderbook.Order{Type: orderbook.Limit, Price: orderbook.MustDecimal("100.01"), Quantity: orderbook.MustDecimal("0.05")}, nil},
+		{"off tick", orderbook.Order{Type: orderbook.Limit, Price: orderbook.MustDecimal("100.001"), Quantity: orderbook.MustDecimal("0.05")}, ErrPriceFilter},
+		{"below min price", orderbook.Order{Type: orderbook.Limit, Price: orderbook.MustDecimal("0.5"), Quantity: orderbook.MustDecimal("20")}, ErrPriceFilter},
+		{"above max price", orderbook.Order{Type: orderbook.Limit, Price: orderbook.MustDecimal("2000000"), Quantity: orderbook.MustDecimal("0.05")}, ErrPriceFilter},
+		{"off stop tick", orderbook.Order{Type: orderbook.StopLimit, Price: orderbook.MustDecimal("100"), StopPrice: orderbook.MustDecimal("99.999"), Quantity: orderbook.MustDecimal("0.05")}, ErrPriceFilter},
+		{"pegged off tick offset", orderbook.Order{Type: orderbook.Limit, Peg: orderbook.PegBestAsk, PegOffset: orderbook.MustDecimal("-0.005"), Quantity: orderbook.MustDecimal("0.05")}, ErrPriceFilter},
+		{"pegged off tick cap", orderbook.Order{Type: orderbook.Limit, Peg: orderbook.PegBestAsk, PegCap: orderbook.MustDecimal("100.001"), Quantity: orderbook.MustDecimal("0.05")}, ErrPriceFilter},
+		{"off lot", orderbook.Order{Type: orderbook.Limit, Price: orderbook.MustDecimal("100"), Quantity: orderbook.MustDecimal("0.050001")}, ErrLotSize},
+		{"below min qty", orderbook.Order{Type: orderbook.Limit, Price: orderbook.MustDecimal("100000"), Quantity: orderbook.MustDecimal("0.00005")}, ErrLotSize},
+		{"above max qty", orderbook.Order{Type: orderbook.Limit, Price: orderbook.MustDecimal("100"), Quantity: orderbook.MustDecimal("101")}, ErrLotSize},
+		{"market above max qty", orderbook.Order{Type: orderbook.Market, Quantity: orderbook.MustDecimal("101")}, ErrMarketLotSize},
+		{"below min notional", orderbook.Order{Type: orderbook.Limit, Price: orderbook.MustDecimal("100"), Quantity: orderbook.MustDecimal("0.04")}, ErrMinNotional},
+		{"market below min notional", orderbook.Order{Type: orderbook.Market, Quantity: orderbook.MustDecimal("0.0001")}, ErrMinNotional},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.order.ID = string(rune('a' + i))
+			tt.order.Side = orderbook.Bid
+			tt.order.TimeInForce = orderbook.IOC
+			report, err := e.PlaceOrder("BTCUSDT", tt.order)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("PlaceOrder() error = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil && report.Status != orderbook.StatusRejected {
+				t.Errorf("Status = %v, want Rejected", report.Status)
+			}
+		})
+	}
+
+	var filterErr *FilterError
+	_, err := e.PlaceOrder("BTCUSDT", orderbook.Order{ID: "x", Side: orderbook.Bid, Price: orderbook.MustDecimal("100.001"), Quantity: orderbook.MustDecimal("1")})
+	if !errors.As(err, &filterErr) || filterErr.Code != -1013 || err.Error() != "Filter failure: PRICE_FILTER" {
+		t.Errorf("PlaceOrder() error = %v, want Binance filter failure", err)
+	}
+
+	if _, err := e.AmendOrder("BTCUSDT", "a1", orderbook.MustDecimal("40000.005"), orderbook.Decimal{}); !errors.Is(err, ErrPriceFilter) {
+		t.Errorf("AmendOrder() error = %v, want ErrPriceFilter", err)
+	}
+	if _, err := e.AmendOrder("BTCUSDT", "a1", orderbook.Decimal{}, orderbook.MustDecimal("0.5")); err != nil {
+		t.Errorf("AmendOrder() error = %v", err)
+	}
+	// The notional of a one-sided amend uses the order's other value.
+	if _, err := e.AmendOrder("BTCUSDT", "a1", orderbook.Decimal{}, orderbook.MustDecimal("0.0001")); !errors.Is(err, ErrMinNotional) {
+		t.Errorf("AmendOrder(quantity) error = %v, want ErrMinNotional", err)
+	}
+	if _, err := e.AmendOrder("BTCUSDT", "a1", orderbook.MustDecimal("9"), orderbook.Decimal{}); !errors.Is(err, ErrMinNotional) {
+		t.Errorf("AmendOrder(price) error = %v, want ErrMinNotional", err)
+	}
+}
@@ -0,0 +1,35 @@
+package engine
+
+import "matching-engine/pkg/orderbook"
+
+// Instrument describes a tradable symbol such as BTCUSDT.
+type Instrument struct {
+	Symbol     string            `json:"symbol"`
+	BaseAsset  string            `json:"baseAsset"`
+	QuoteAsset string            `json:"quoteAsset"`
+	TickSize   orderbook.Decimal `json:"tickSize"` // Minimum price increment
+	LotSize    orderbook.Decimal `json:"lotSize"`  // Minimum quantity increment
+
+	// Trading rules, each disabled when zero.
+	MinPrice    orderbook.Decimal `json:"minPrice"`
+	MaxPrice    orderbook.Decimal `json:"maxPrice"`
+	MinQuantity orderbook.Decimal `json:"minQty"`
+	MaxQuantity orderbook.Decimal `json:"maxQty"`
+	MinNotional orderbook.Decimal `json:"minNotional"` // Minimum price * quantity
+}
+
+func (i Instrument) validate() error {
+	if i.Symbol == "" || i.BaseAsset == "" || i.QuoteAsset == "" {
+		return ErrInvalidInstrument
+	}
+	if !i.TickSize.IsPositive() || !i.LotSize.IsPositive() {
+		return ErrInvalidInstrument
+	}
+	if !i.MaxPrice.IsZero() && i.MaxPrice.LessThan(i.MinPrice) {
+		return ErrInvalidInstrument
+	}
+	if !i.MaxQuantity.IsZero() && i.MaxQuantity.LessThan(i.MinQuantity) {
+		return ErrInvalidInstrument
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package engine
+
+import (
+	"sync"
+
+	"matching-engine/pkg/orderbook"
+)
+
+// Balance is an account's holding of one asset. Locked funds back open orders
+// and cannot be withdrawn or spent on other orders.
+type Balance struct {
+	Available orderbook.Decimal `json:"available"`
+	Locked    orderbook.Decimal `json:"locked"`
+}
+
+// Ledger keeps per-asset balances of accounts and funds their orders: placing
+// an order locks the quote asset for a buy or the base asset for a sell, fills
+// move funds between buyer and seller, and whatever is left locked goes back
+// to the account when the order is done.
+//
+// Buys are funded at their limit price. Buys without one lock at their
+// ProtectionPrice, and pegged buys at their PegCap. An order list is funded
+// once, at the largest amount any of its members needs, as only one member
+// can trade before the others are canceled.
+type Ledger struct {
+	sync.RWMutex
+	accounts map[string]map[string]*Balance // Account, then asset
+	holds    map[holdKey]*hold
+}
+
+type holdKey struct {
+	symbol  string
+	orderID string
+}
+
+// hold is what the ledger knows of one funded order. Members of an order list
+// share their funds.
+type hold struct {
+	account   string
+	asset     string // Locked asset
+	proceeds  string // Asset received from fills
+	side      orderbook.OrderSide
+	price     orderbook.Decimal // Quote locked per unit of a buy, zero for sells
+	fixed     bool              // price does not follow the order's price
+	remaining orderbook.Decimal
+	funds     *funds
+}
+
+// funds is the part of an account's locked balance that backs one order, or
+// all members of an order list.
+type funds struct {
+	amount orderbook.Decimal // Still locked
+	holds  []*hold           // Orders backed by amount that are not done
+}
+
+// required is the amount that backs quantity left on the order at price.
+func (h *hold) required(price, quantity orderbook.Decimal) (orderbook.Decimal, error) {
+	if h.side == orderbook.Ask {
+		return quantity, nil
+	}
+	return price.Mul(quantity)
+}
+
+func NewLedger() *Ledger {
+	return &Ledger{
+		accounts: make(map[string]map[string]*Balance),
+		holds:    make(map[holdKey]*hold),
+	}
+}
+
+// Deposit credits amount to the available balance.
+func (l *Ledger) Deposit(account, asset string, amount orderbook.Decimal) error {
+	if account == "" || asset == "" || !amount.IsPositive() {
+		return ErrInvalidAmount
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	balance := l.balance(account, asset)
+	balance.Available = balance.Available.Add(amount)
+	return nil
+}
+
+// Withdraw debits amount from the available balance.
+func (l *Ledger) Withdraw(account, asset string, amount orderbook.Decimal) error {
+	if !amount.IsPositive() {
+		return ErrInvalidAmount
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	balance := l.balance(account, asset)
+	if balance.Available.LessThan(amount) {
+		return ErrInsufficientBalance
+	}
+	balance.Available = balance.Available.Sub(amount)
+	return nil
+}
+
+// Balance returns an account's balance of one asset, zero if it never held
+// any.
+func (l *Ledger) Balance(account, asset string) Balance {
+	l.RLock()
+	defer l.RUnlock()
+
+	if balance, found := l.accounts[account][asset]; found {
+		return *balance
+	}
+	return Balance{}
+}
+
+// Balances returns every asset an account holds or has held.
+func (l *Ledger) Balances(account string) map[string]Balance {
+	l.RLock()
+	defer l.RUnlock()
+
+	balances := make(map[string]Balance, len(l.accounts[account]))
+	for asset, balance := range l.accounts[account] {
+		balances[asset] = *balance
+	}
+	return balances
+}
+
+func (l *Ledger) balance(account, asset string) *Balance {
+	assets, found := l.accounts[account]
+	if !found {
+		assets = make(map[string]*Balance)
+		l.accounts[account] = assets
+	}
+	balance, found := assets[asset]
+	if !found {
+		balance = &Balance{}
+		assets[asset] = balance
+	}
+	return balance
+}
+
+// reserve locks the funds an order needs before it is sent to the book.
+func (l *Ledger) reserve(instrument Instrument, order *orderbook.Order) error {
+	return l.reserveList(instrument, []orderbook.Order{*order})
+}
+
+// reserveList locks the funds of orders that share them: the largest amount
+// any one of them needs.
+func (l *Ledger) reserveList(instrument Instrument, orders []orderbook.Order) error {
+	f := &funds{}
+	var amount orderbook.Decimal
+	for i := range orders {
+		h, required, err := newHold(instrument, &orders[i])
+		if err != nil {
+			return err
+		}
+		h.funds = f
+		f.holds = append(f.holds, h)
+		if required.GreaterThan(amount) {
+			amount = required
+		}
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	for i, order := range orders {
+		key := holdKey{symbol: instrument.Symbol, orderID: order.ID}
+		if _, exists := l.holds[key]; exists {
+			return orderbook.ErrDuplicateOrder
+		}
+		for _, other := range orders[:i] {
+			if other.ID == order.ID {
+				return orderbook.ErrDuplicateOrder
+			}
+		}
+	}
+	if err := l.lock(f.holds[0], amount); err != nil {
+		return err
+	}
+	for i, order := range orders {
+		l.holds[holdKey{symbol: instrument.Symbol, orderID: order.ID}] = f.holds[i]
+	}
+	return nil
+}
+
+// newHold returns the hold of an order and the amount it needs.
+func newHold(instrument Instrument, order *orderbook.Order) (*hold, orderbook.Decimal, error) {
+	h := &hold{
+		account:   order.AccountID,
+		asset:     instrument.BaseAsset,
+		proceeds:  instrument.QuoteAsset,
+		side:      order.Side,
+		remaining: order.Quantity,
+	}
+	if order.Side == orderbook.Bid {
+		h.asset, h.proceeds = instrument.QuoteAsset, instrument.BaseAsset
+		switch {
+		case order.Peg != orderbook.PegNone:
+			h.price, h.fixed = order.PegCap, true
+		case order.Type == orderbook.Market || order.Type == orderbook.Stop:
+			h.price, h.fixed = order.ProtectionPrice, true
+		default:
+			h.price = order.Price
+		}
+		if !h.price.IsPositive() {
+			return nil, orderbook.Decimal{}, ErrUnfundedOrder
+		}
+	}
+
+	amount, err := h.required(h.price, order.Quantity)
+	if err != nil {
+		return nil, orderbook.Decimal{}, err
+	}
+	return h, amount, nil
+}
+
+// reserveAmend locks what an amend to newPrice and newQty needs beyond the
+// current hold. Zero values keep the order's price or quantity, as in the
+// book. Orders the ledger does not fund are left to the book.
+func (l *Ledger) reserveAmend(symbol, orderID string, newPrice, newQty orderbook.Decimal) error {
+	l.Lock()
+	defer l.Unlock()
+
+	h, found := l.holds[holdKey{symbol: symbol, orderID: orderID}]
+	if !found {
+		return nil
+	}
+	price, quantity := h.price, h.remaining
+	if !newPrice.IsZero() && !h.fixed {
+		price = newPrice
+	}
+	if !newQty.IsZero() {
+		quantity = newQty
+	}
+
+	amount, err := h.required(price, quantity)
+	if err != nil {
+		return err
+	}
+	if amount.GreaterThan(h.funds.amount) {
+		return l.lock(h, amount.Sub(h.funds.amount))
+	}
+	return nil
+}
+
+// settle returns funds locked beyond what the order still needs, e.g. after an
+// amend the book refused.
+func (l *Ledger) settle(symbol, orderID string) {
+	l.Lock()
+	defer l.Unlock()
+
+	if h, found := l.holds[holdKey{symbol: symbol, orderID: orderID}]; found {
+		l.release(h, h.excess())
+	}
+}
+
+// apply follows an order event of the instrument's book.
+func (l *Ledger) apply(instrument Instrument, event orderbook.Event) {
+	l.Lock()
+	defer l.Unlock()
+
+	key := holdKey{symbol: instrument.Symbol, orderID: event.OrderID}
+	h, found := l.holds[key]
+	if !found {
+		return
+	}
+
+	switch event.Type {
+	case orderbook.EventOrderAccepted, orderbook.EventOrderAmended:
+		// Post-only repricing and amends move the price of a limit buy.
+		if !h.fixed && h.side == orderbook.Bid {
+			h.price = event.Price
+		}
+		h.remaining = event.Remaining
+		l.release(h, h.excess())
+
+	case orderbook.EventOrderPartiallyFilled, orderbook.EventOrderFilled:
+		l.fill(h, event.Trade)
+		h.remaining = event.Remaining
+		if event.Type == orderbook.EventOrderFilled {
+			l.done(key, h)
+		} else {
+			l.release(h, h.excess())
+		}
+
+	case orderbook.EventOrderCanceled, orderbook.EventOrderExpired, orderbook.EventOrderRejected:
+		l.done(key, h)
+	}
+}
+
+// done forgets an order that left the book. Its funds go back once no other
+// order shares them; until then only what the rest do not need is released.
+func (l *Ledger) done(key holdKey, h *hold) {
+	delete(l.holds, key)
+	f := h.funds
+	for i, other := range f.holds {
+		if other == h {
+			f.holds = append(f.holds[:i], f.holds[i+1:]...)
+			break
+		}
+	}
+
+	switch len(f.holds) {
+	case 0:
+		l.release(h, f.amount)
+	case 1:
+		l.release(h, f.holds[0].excess())
+	}
+}
+
+// fill pays for one trade out of the hold and credits what it bought or sold.
+func (l *Ledger) fill(h *hold, trade *orderbook.Trade) {
+	cost, err := trade.Price.Mul(trade.Quantity)
+	if err != nil {
+		return
+	}
+	spent, received := cost, trade.Quantity
+	if h.side == orderbook.Ask {
+		spent, received = trade.Quantity, cost
+	}
+
+	locked := l.balance(h.account, h.asset)
+	locked.Locked = locked.Locked.Sub(spent)
+	h.funds.amount = h.funds.amount.Sub(spent)
+
+	proceeds := l.balance(h.account, h.proceeds)
+	proceeds.Available = proceeds.Available.Add(received)
+}
+
+// excess is the amount locked beyond what the rest of the order needs. Funds
+// shared with other orders have none: any of them may still need it all.
+func (h *hold) excess() orderbook.Decimal {
+	if len(h.funds.holds) > 1 {
+		return orderbook.Decimal{}
+	}
+	amount, err := h.required(h.price, h.remaining)
+	if err != nil || !h.funds.amount.GreaterThan(amount) {
+		return orderbook.Decimal{}
+	}
+	return h.funds.amount.Sub(amount)
+}
+
+func (l *Ledger) lock(h *hold, amount orderbook.Decimal) error {
+	balance := l.balance(h.account, h.asset)
+	if balance.Available.LessThan(amount) {
+		return ErrInsufficientBalance
+	}
+	balance.Available = balance.Available.Sub(amount)
+	balance.Locked = balance.Locked.Add(amount)
+	h.funds.amount = h.funds.amount.Add(amount)
+	return nil
+}
+
+func (l *Ledger) release(h *hold, amount orderbook.Decimal) {
+	if amount.IsZero() {
+		return
+	}
+	balance := l.balance(h.account, h.asset)
+	balance.Available = balance.Available.Add(amount)
+	balance.Locked = balance.Locked.Sub(amount)
+	h.funds.amount = h.funds.amount.Sub(amount)
+}
@@ -0,0 +1,209 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"matching-engine/pkg/orderbook"
+)
+
+func newFundedEngine(t *testing.T) (*Engine, *Ledger) {
+	t.Helper()
+	ledger := NewLedger()
+	e := NewEngine(WithLedger(ledger))
+	if err := e.List(btcusdt()); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	deposits := []struct{ account, asset, amount string }{
+		{"alice", "USDT", "1000"},
+		{"bob", "BTC", "10"},
+	}
+	for _, d := range deposits {
+		if err := ledger.Deposit(d.account, d.asset, orderbook.MustDecimal(d.amount)); err != nil {
+			t.Fatalf("Deposit(%s, %s) error = %v", d.account, d.asset, err)
+		}
+	}
+	return e, ledger
+}
+
+func wantBalance(t *testing.T, ledger *Ledger, account, asset, available, locked string) {
+	t.Helper()
+	got := ledger.Balance(account, asset)
+	if !got.Available.Equal(orderbook.MustDecimal(available)) || !got.Locked.Equal(orderbook.MustDecimal(locked)) {
+		t.Errorf("Balance(%s, %s) = %s available, %s locked, want %s, %s", account, asset, got.Available, got.Locked, available, locked)
+	}
+}
+
+func TestLedgerSettlement(t *testing.T) {
+	e, ledger := newFundedEngine(t)
+
+	// 2 BTC at 100 locks 200 USDT until it fills at the better ask price.
+	if _, err := e.PlaceOrder("BTCUSDT", orderbook.Order{ID: "b1", AccountID: "alice", Side: orderbook.Bid, Price: orderbook.MustDecimal("100"), Quantity: orderbook.MustDecimal("2")}); err != nil {
+		t.Fatalf("PlaceOrder(b1) error = %v", err)
+	}
+	wantBalance(t, ledger, "alice", "USDT", "800", "200")
+
+	if _, err := e.PlaceOrder("BTCUSDT", orderbook.Order{ID: "a1", AccountID: "bob", Side: orderbook.Ask, Price: orderbook.MustDecimal("120"), Quantity: orderbook.MustDecimal("3")}); err != nil {
+		t.Fatalf("PlaceOrder(a1) error = %v", err)
+	}
+	wantBalance(t, ledger, "bob", "BTC", "7", "3")
+
+	// Crossing at 120 fills 1 BTC, and the unspent 10 USDT of the 130 limit
+	// go back right away.
+	report, err := e.PlaceOrder("BTCUSDT", orderbook.Order{ID: "b2", AccountID: "alice", Side: orderbook.Bid, Price: orderbook.MustDecimal("130"), Quantity: orderbook.MustDecimal("1")})
+	if err != nil || report.Status != orderbook.StatusFilled {
+		t.Fatalf("PlaceOrder(b2) = %+v, %v, want filled", report, err)
+	}
+	wantBalance(t, ledger, "alice", "USDT", "680", "200")
+	wantBalance(t, ledger, "alice", "BTC", "1", "0")
+	wantBalance(t, ledger, "bob", "BTC", "7", "2")
+	wantBalance(t, ledger, "bob", "USDT", "120", "0")
+
+	if _, err := e.CancelOrder("BTCUSDT", "b1"); err != nil {
+		t.Fatalf("CancelOrder(b1) error = %v", err)
+	}
+	wantBalance(t, ledger, "alice", "USDT", "880", "0")
+
+	if _, err := e.Delist("BTCUSDT"); err != nil {
+		t.Fatalf("Delist() error = %v", err)
+	}
+	wantBalance(t, ledger, "bob", "BTC", "9", "0")
+}
+
+func TestLedgerRiskChecks(t *testing.T) {
+	tests := []struct {
+		name  string
+		order orderbook.Order
+		want  error
+	}{
+		{"bid over balance", orderbook.Order{AccountID: "alice", Side: orderbook.Bid, Price: orderbook.MustDecimal("100"), Quantity: orderbook.MustDecimal("10.1")}, ErrInsufficientBalance},
+		{"ask over balance", orderbook.Order{AccountID: "bob", Side: orderbook.Ask, Price: orderbook.MustDecimal("100"), Quantity: orderbook.MustDecimal("11")}, ErrInsufficientBalance},
+		{"wrong asset", orderbook.Order{AccountID: "alice", Side: orderbook.Ask, Price: orderbook.MustDecimal("100"), Quantity: orderbook.MustDecimal("1")}, ErrInsufficientBalance},
+		{"market bid without protection", orderbook.Order{AccountID: "alice", Side: orderbook.Bid, Type: orderbook.Market, Quantity: orderbook.MustDecimal("1")}, ErrUnfundedOrder},
+		{"pegged bid without cap", orderbook.Order{AccountID: "alice", Side: orderbook.Bid, Peg: orderbook.PegBestBid, Quantity: orderbook.MustDecimal("1")}, ErrUnfundedOrder},
+		{"market ask", orderbook.Order{AccountID: "bob", Side: orderbook.Ask, Type: orderbook.Market, Quantity: orderbook.MustDecimal("10")}, nil},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ledger := newFundedEngine(t)
+			tt.order.ID = string(rune('a' + i))
+			if _, err := e.PlaceOrder("BTCUSDT", tt.order); !errors.Is(err, tt.want) {
+				t.Fatalf("PlaceOrder() error = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				if _, err := e.GetOrder("BTCUSDT", tt.order.ID); err == nil {
+					t.Errorf("rejected order reached the book")
+				}
+			}
+			// Nothing stays locked: rejected orders never lock, and the
+			// market ask is canceled for want of bids.
+			wantBalance(t, ledger, "alice", "USDT", "1000", "0")
+			wantBalance(t, ledger, "bob", "BTC", "10", "0")
+		})
+	}
+}
+
+func TestLedgerMarketBid(t *testing.T) {
+	e, ledger := newFundedEngine(t)
+	e.PlaceOrder("BTCUSDT", orderbook.Order{ID: "a1", AccountID: "bob", Side: orderbook.Ask, Price: orderbook.MustDecimal("90"), Quantity: orderbook.MustDecimal("1")})
+
+	// Funded at the protection price; the remainder is canceled once the book
+	// runs dry and its hold released.
+	report, err := e.PlaceOrder("BTCUSDT", orderbook.Order{ID: "b1", AccountID: "alice", Side: orderbook.Bid, Type: orderbook.Market, ProtectionPrice: orderbook.MustDecimal("100"), Quantity: orderbook.MustDecimal("5")})
+	if err != nil || len(report.Trades) != 1 {
+		t.Fatalf("PlaceOrder(b1) = %+v, %v", report, err)
+	}
+	wantBalance(t, ledger, "alice", "USDT", "910", "0")
+	wantBalance(t, ledger, "alice", "BTC", "1", "0")
+	wantBalance(t, ledger, "bob", "USDT", "90", "0")
+
+	if _, err := e.PlaceOrder("BTCUSDT", orderbook.Order{ID: "b2", AccountID: "alice", Side: orderbook.Bid, Type: orderbook.Market, ProtectionPrice: orderbook.MustDecimal("100"), Quantity: orderbook.MustDecimal("10")}); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("PlaceOrder(b2) error = %v, want ErrInsufficientBalance", err)
+	}
+}
+
+func TestLedgerAmend(t *testing.T) {
+	e, ledger := newFundedEngine(t)
+	if _, err := e.PlaceOrder("BTCUSDT", orderbook.Order{ID: "b1", AccountID: "alice", Side: orderbook.Bid, Price: orderbook.MustDecimal("100"), Quantity: orderbook.MustDecimal("5")}); err != nil {
+		t.Fatalf("PlaceOrder(b1) error = %v", err)
+	}
+
+	if _, err := e.AmendOrder("BTCUSDT", "b1", orderbook.MustDecimal("201"), orderbook.Decimal{}); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("AmendOrder(201) error = %v, want ErrInsufficientBalance", err)
+	}
+	wantBalance(t, ledger, "alice", "USDT", "500", "500")
+
+	if _, err := e.AmendOrder("BTCUSDT", "b1", orderbook.MustDecimal("150"), orderbook.Decimal{}); err != nil {
+		t.Fatalf("AmendOrder(150) error = %v", err)
+	}
+	wantBalance(t, ledger, "alice", "USDT", "250", "750")
+
+	if _, err := e.AmendOrder("BTCUSDT", "b1", orderbook.Decimal{}, orderbook.MustDecimal("2")); err != nil {
+		t.Fatalf("AmendOrder(qty 2) error = %v", err)
+	}
+	wantBalance(t, ledger, "alice", "USDT", "700", "300")
+
+	// A refused amend gives back what it locked.
+	e.SetSession("BTCUSDT", orderbook.SessionHalted)
+	if _, err := e.AmendOrder("BTCUSDT", "b1", orderbook.MustDecimal("200"), orderbook.Decimal{}); !errors.Is(err, orderbook.ErrTradingHalted) {
+		t.Errorf("AmendOrder() while halted error = %v, want ErrTradingHalted", err)
+	}
+	wantBalance(t, ledger, "alice", "USDT", "700", "300")
+}
+
+func TestLedgerOrderList(t *testing.T) {
+	e, ledger := newFundedEngine(t)
+	if err := ledger.Deposit("carol", "BTC", orderbook.MustDecimal("1")); err != nil {
+		t.Fatalf("Deposit() error = %v", err)
+	}
+
+	// Both legs of a 1 BTC OCO sell are backed by the same 1 BTC.
+	sell := orderbook.OrderList{ID: "sell", Orders: []orderbook.Order{
+		{ID: "l1", AccountID: "carol", Side: orderbook.Ask, Price: orderbook.MustDecimal("110"), Quantity: orderbook.MustDecimal("1")},
+		{ID: "s1", AccountID: "carol", Side: orderbook.Ask, Type: orderbook.Stop, StopPrice: orderbook.MustDecimal("95"), Quantity: orderbook.MustDecimal("1")},
+	}}
+	if _, err := e.PlaceOrderList("BTCUSDT", sell); err != nil {
+		t.Fatalf("PlaceOrderList(sell) error = %v", err)
+	}
+	wantBalance(t, ledger, "carol", "BTC", "0", "1")
+
+	// The limit fills, the stop is canceled and nothing stays locked.
+	if _, err := e.PlaceOrder("BTCUSDT", orderbook.Order{ID: "b1", AccountID: "alice", Side: orderbook.Bid, Price: orderbook.MustDecimal("110"), Quantity: orderbook.MustDecimal("1")}); err != nil {
+		t.Fatalf("PlaceOrder(b1) error = %v", err)
+	}
+	wantBalance(t, ledger, "carol", "BTC", "0", "0")
+	wantBalance(t, ledger, "carol", "USDT", "110", "0")
+
+	// A buy list locks what its dearest member needs: 2 at 90 rather than 1
+	// at the 120 protection price.
+	buy := orderbook.OrderList{ID: "buy", Orders: []orderbook.Order{
+		{ID: "l2", AccountID: "alice", Side: orderbook.Bid, Price: orderbook.MustDecimal("90"), Quantity: orderbook.MustDecimal("2")},
+		{ID: "s2", AccountID: "alice", Side: orderbook.Bid, Type: orderbook.Stop, StopPrice: orderbook.MustDecimal("115"), ProtectionPrice: orderbook.MustDecimal("120"), Quantity: orderbook.MustDecimal("1")},
+	}}
+	if _, err := e.PlaceOrderList("BTCUSDT", buy); err != nil {
+		t.Fatalf("PlaceOrderList(buy) error = %v", err)
+	}
+	wantBalance(t, ledger, "alice", "USDT", "710", "180")
+
+	// A partial fill cancels the stop; the limit keeps what its rest needs.
+	if _, err := e.PlaceOrder("BTCUSDT", orderbook.Order{ID: "a1", AccountID: "bob", Side: orderbook.Ask, Price: orderbook.MustDecimal("90"), Quantity: orderbook.MustDecimal("1")}); err != nil {
+		t.Fatalf("PlaceOrder(a1) error = %v", err)
+	}
+	wantBalance(t, ledger, "alice", "USDT", "710", "90")
+
+	if _, err := e.CancelOrder("BTCUSDT", "l2"); err != nil {
+		t.Fatalf("CancelOrder(l2) error = %v", err)
+	}
+	wantBalance(t, ledger, "alice", "USDT", "800", "0")
+
+	// A list the account cannot fund locks nothing.
+	if _, err := e.PlaceOrderList("BTCUSDT", orderbook.OrderList{ID: "big", Orders: []orderbook.Order{
+		{ID: "l3", AccountID: "alice", Side: orderbook.Bid, Price: orderbook.MustDecimal("90"), Quantity: orderbook.MustDecimal("2")},
+		{ID: "l4", AccountID: "alice", Side: orderbook.Bid, Price: orderbook.MustDecimal("80"), Quantity: orderbook.MustDecimal("11")},
+	}}); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("PlaceOrderList(big) error = %v, want ErrInsufficientBalance", err)
+	}
+	wantBalance(t, ledger, "alice", "USDT", "800", "0")
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"matching-engine/pkg/orderbook"
+)
+
+// SessionConfig is the daily trading schedule of one symbol, as read from
+// configuration.
+type SessionConfig struct {
+	Symbol   string
+	Location string // IANA time zone of the schedule, UTC if empty
+	Schedule []orderbook.ScheduledTransition
+}
+
+// Schedule drives a listed symbol through its daily schedule until ctx is done
+// or the symbol is delisted. onError, if not nil, receives the transitions the
+// book refused.
+func (e *Engine) Schedule(ctx context.Context, session SessionConfig, onError func(orderbook.ScheduledTransition, error)) error {
+	m, err := e.market(session.Symbol)
+	if err != nil {
+		return err
+	}
+	location, err := time.LoadLocation(session.Location)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	if len(session.Schedule) == 0 {
+		return ErrInvalidSchedule
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	context.AfterFunc(m.listed, cancel)
+
+	scheduler := orderbook.NewSessionScheduler(m.book, session.Schedule, location, onError)
+	go func() {
+		defer cancel()
+		scheduler.Run(ctx)
+	}()
+	return nil
+}
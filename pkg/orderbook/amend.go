@@ -0,0 +1,87 @@
+package orderbook
+
+import "time"
+
+// AmendOrder changes the price and remaining quantity of a resting order
+// atomically. A zero newPrice or newQty leaves that field unchanged. Reducing
+// the quantity at the same price keeps the order's queue position; a price
+// change or quantity increase re-queues it with a new timestamp, and at a new
+// price it may trade as a taker like a freshly placed order.
+func (ob *OrderBook) AmendOrder(orderID string, newPrice, newQty Decimal) (ExecutionReport, error) {
+	ob.Lock()
+	defer ob.Unlock()
+	ob.begin(time.Now())
+
+	return ob.amendOrder(orderID, newPrice, newQty)
+}
+
+func (ob *OrderBook) amendOrder(orderID string, newPrice, newQty Decimal) (ExecutionReport, error) {
+	defer ob.repeg()
+
+	if newPrice.Sign() < 0 {
+		return ExecutionReport{OrderID: orderID, Status: StatusRejected}, ErrInvalidPrice
+	}
+	if newQty.Sign() < 0 {
+		return ExecutionReport{OrderID: orderID, Status: StatusRejected}, ErrInvalidQuantity
+	}
+	if !inRange(newPrice) || !inRange(newQty) {
+		return ExecutionReport{OrderID: orderID, Status: StatusRejected}, ErrOutOfRange
+	}
+
+	e, found := ob.orders[orderID]
+	if !found {
+		return ExecutionReport{OrderID: orderID, Status: StatusRejected}, ErrOrderNotFound
+	}
+	if err := ob.session.admits(); err != nil {
+		return ExecutionReport{OrderID: orderID, Status: StatusRejected}, err
+	}
+	order := e.Value.(*Order)
+	if order.pegged() && !newPrice.IsZero() && !newPrice.Equal(order.Price) {
+		return ExecutionReport{OrderID: orderID, Status: StatusRejected}, ErrInvalidPeg
+	}
+
+	if newPrice.IsZero() {
+		newPrice = order.Price
+	}
+	if newQty.IsZero() {
+		newQty = order.Quantity
+	}
+
+	if newPrice.Equal(order.Price) && newQty.Cmp(order.Quantity) <= 0 {
+		order.Quantity = newQty
+		if order.isIceberg() {
+			order.visible = minDecimal(order.visible, newQty)
+		}
+		ob.emitOrder(EventOrderAmended, order, "")
+		ob.emitLevel(order.Side, order.Price)
+		return ExecutionReport{
+			OrderID:           order.ID,
+			Status:            StatusNew,
+			Price:             order.Price,
+			RemainingQuantity: order.Quantity,
+		}, nil
+	}
+
+	amended := *order
+	amended.Price = newPrice
+	amended.Quantity = newQty
+	amended.Timestamp = ob.now
+
+	if amended.isIceberg() && amended.DisplayQuantity.GreaterThan(newQty) {
+		amended.DisplayQuantity = newQty
+	}
+
+	// Admit before the original leaves the book, so a rejected amend has no
+	// side effects.
+	limit, err := ob.admit(&amended)
+	if err != nil {
+		return ExecutionReport{OrderID: orderID, Status: StatusRejected}, err
+	}
+
+	ob.remove(e)
+	ob.emitOrder(EventOrderAmended, &amended, "")
+
+	report := ob.execute(&amended, limit)
+	report.Triggered = ob.activateStops()
+	return report, nil
+}
@@ -0,0 +1,240 @@
+package orderbook
+
+import (
+	"container/list"
+	"sort"
+	"time"
+
+	"github.com/emirpasic/gods/v2/trees/redblacktree"
+)
+
+// AuctionIndication is the price a call auction would uncross at if it ended
+// now, and the volume that would trade there.
+type AuctionIndication struct {
+	Price     Decimal   `json:"price"`
+	Volume    Decimal   `json:"volume"`
+	Imbalance Decimal   `json:"imbalance"` // Quantity left unmatched at Price
+	Side      OrderSide `json:"side"`      // Side of the imbalance
+}
+
+type AuctionResult struct {
+	Price     Decimal
+	Volume    Decimal
+	Trades    []Trade
+	Triggered []ExecutionReport
+}
+
+// StartAuction moves the book to the PreOpen call auction. Until Uncross,
+// limit orders accumulate in the book without matching, even when they cross,
+// and every change to the book publishes an AuctionIndicative event. Orders
+// that cannot rest are rejected and stop orders stay dormant.
+func (ob *OrderBook) StartAuction() error {
+	ob.Lock()
+	defer ob.Unlock()
+	ob.begin(time.Now())
+
+	_, err := ob.setSession(SessionPreOpen)
+	return err
+}
+
+// Uncross ends the call auction. All crossing orders trade at the single price
+// that maximizes the executed volume; ties go to the price with the smallest
+// imbalance, then the one closest to the last trade price, then the lower one.
+// Orders trade in price-time priority and the older order of each pair is the
+// maker. The book moves on to continuous trading afterwards.
+func (ob *OrderBook) Uncross() (AuctionResult, error) {
+	ob.Lock()
+	defer ob.Unlock()
+	ob.begin(time.Now())
+
+	if ob.session != SessionPreOpen {
+		return AuctionResult{}, ErrNoAuction
+	}
+	return ob.setSession(SessionContinuous)
+}
+
+func (ob *OrderBook) uncross() AuctionResult {
+	indication, found := ob.indicate()
+	ob.transition(SessionContinuous, ReasonRequested, Decimal{})
+
+	var result AuctionResult
+	if found {
+		result.Price = indication.Price
+		result.Volume = indication.Volume
+		result.Trades = ob.cross(indication.Price)
+	}
+	ob.emit(Event{Type: EventAuctionUncrossed, Price: result.Price, Quantity: result.Volume})
+
+	result.Triggered = ob.activateStops()
+	return result
+}
+
+// IndicativePrice returns the current auction indication. It reports false
+// outside an auction and while bids and asks do not cross.
+func (ob *OrderBook) IndicativePrice() (AuctionIndication, bool) {
+	ob.RLock()
+	defer ob.RUnlock()
+
+	if ob.session != SessionPreOpen {
+		return AuctionIndication{}, false
+	}
+	return ob.indicate()
+}
+
+// cross trades the crossing orders at price until one side no longer crosses
+// it.
+func (ob *OrderBook) cross(price Decimal) []Trade {
+	var trades []Trade
+	for !ob.Bids.Empty() && !ob.Asks.Empty() {
+		bidIter := ob.Bids.Iterator()
+		askIter := ob.Asks.Iterator()
+		bidIter.Next()
+		askIter.Next()
+
+		bidPrice, askPrice := bidIter.Key(), askIter.Key()
+		if bidPrice.LessThan(price) || askPrice.GreaterThan(price) {
+			break
+		}
+
+		bidQueue, askQueue := bidIter.Value(), askIter.Value()
+		bidElement, askElement := bidQueue.Front(), askQueue.Front()
+		bidOrder := bidElement.Value.(*Order)
+		askOrder := askElement.Value.(*Order)
+
+		maker, taker := bidOrder, askOrder
+		if askOrder.Timestamp.Before(bidOrder.Timestamp) {
+			maker, taker = askOrder, bidOrder
+		}
+
+		if ob.isSelfTrade(maker, taker) {
+			ob.preventSelfTrade(maker, taker)
+			continue
+		}
+
+		trade := ob.newTrade(maker, taker, price, minDecimal(bidOrder.displayed(), askOrder.displayed()))
+		trades = append(trades, trade)
+
+		ob.consume(Bid, bidPrice, bidQueue, bidElement, &trade)
+		ob.consume(Ask, askPrice, askQueue, askElement, &trade)
+	}
+	return trades
+}
+
+// cumulativeLevel is a price with the total quantity resting at it or better.
+type cumulativeLevel struct {
+	price Decimal
+	total Decimal
+}
+
+// cumulative lists the levels of a side best first. Iceberg reserves count, as
+// they trade in the auction like displayed quantity. Totals are capped at
+// maxLevelQuantity, which only blurs the choice of price between volumes no
+// level could hold.
+func cumulative(tree *redblacktree.Tree[Decimal, *list.List]) []cumulativeLevel {
+	levels := make([]cumulativeLevel, 0, tree.Size())
+	total := Decimal{}
+	for iter := tree.Iterator(); iter.Next(); {
+		for e := iter.Value().Front(); e != nil; e = e.Next() {
+			sum, ok := add(total, e.Value.(*Order).Quantity)
+			if !ok || sum.GreaterThan(maxLevelQuantity) {
+				sum = maxLevelQuantity
+			}
+			total = sum
+		}
+		levels = append(levels, cumulativeLevel{price: iter.Key(), total: total})
+	}
+	return levels
+}
+
+// indicate runs the uncrossing algorithm over every price resting between the
+// best ask and the best bid.
+func (ob *OrderBook) indicate() (AuctionIndication, bool) {
+	bids, asks := cumulative(ob.Bids), cumulative(ob.Asks)
+	if len(bids) == 0 || len(asks) == 0 || bids[0].price.LessThan(asks[0].price) {
+		return AuctionIndication{}, false
+	}
+
+	var best AuctionIndication
+	found := false
+	for _, levels := range [][]cumulativeLevel{bids, asks} {
+		for _, level := range levels {
+			price := level.price
+			if price.LessThan(asks[0].price) || price.GreaterThan(bids[0].price) {
+				continue
+			}
+
+			demand := demandAt(bids, price)
+			supply := supplyAt(asks, price)
+			candidate := AuctionIndication{Price: price, Volume: minDecimal(demand, supply)}
+			if demand.GreaterThan(supply) {
+				candidate.Imbalance, candidate.Side = demand.Sub(supply), Bid
+			} else {
+				candidate.Imbalance, candidate.Side = supply.Sub(demand), Ask
+			}
+
+			if !found || ob.betterUncross(candidate, best) {
+				best, found = candidate, true
+			}
+		}
+	}
+	return best, found
+}
+
+// demandAt is the bid quantity willing to buy at price.
+func demandAt(bids []cumulativeLevel, price Decimal) Decimal {
+	i := sort.Search(len(bids), func(i int) bool { return bids[i].price.LessThan(price) })
+	if i == 0 {
+		return Decimal{}
+	}
+	return bids[i-1].total
+}
+
+// supplyAt is the ask quantity willing to sell at price.
+func supplyAt(asks []cumulativeLevel, price Decimal) Decimal {
+	i := sort.Search(len(asks), func(i int) bool { return asks[i].price.GreaterThan(price) })
+	if i == 0 {
+		return Decimal{}
+	}
+	return asks[i-1].total
+}
+
+func (ob *OrderBook) betterUncross(a, b AuctionIndication) bool {
+	if c := a.Volume.Cmp(b.Volume); c != 0 {
+		return c > 0
+	}
+	if c := a.Imbalance.Cmp(b.Imbalance); c != 0 {
+		return c < 0
+	}
+	if !ob.lastPrice.IsZero() {
+		if c := distance(a.Price, ob.lastPrice).Cmp(distance(b.Price, ob.lastPrice)); c != 0 {
+			return c < 0
+		}
+	}
+	return a.Price.LessThan(b.Price)
+}
+
+func distance(a, b Decimal) Decimal {
+	d := a.Sub(b)
+	if d.Sign() < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+// emitIndication publishes the current auction indication, with a zero price
+// and volume while the book does not cross.
+func (ob *OrderBook) emitIndication() {
+	if len(ob.listeners) == 0 {
+		ob.eventSequence++
+		return
+	}
+
+	indication, _ := ob.indicate()
+	ob.emit(Event{
+		Type:      EventAuctionIndicative,
+		Side:      indication.Side,
+		Price:     indication.Price,
+		Quantity:  indication.Volume,
+		Remaining: indication.Imbalance,
+	})
+}
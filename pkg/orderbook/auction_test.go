@@ -0,0 +1,139 @@
+package orderbook
+
+import "testing"
+
+func TestAuctionUncross(t *testing.T) {
+	tests := []struct {
+		name       string
+		lastPrice  string
+		orders     []Order
+		wantPrice  string
+		wantVolume string
+		wantTrades int
+	}{
+		{
+			name: "maximum volume",
+			orders: []Order{
+				{ID: "b1", Side: Bid, Price: MustDecimal("102"), Quantity: MustDecimal("3")},
+				{ID: "b2", Side: Bid, Price: MustDecimal("101"), Quantity: MustDecimal("2")},
+				{ID: "b3", Side: Bid, Price: MustDecimal("99"), Quantity: MustDecimal("5")},
+				{ID: "a1", Side: Ask, Price: MustDecimal("98"), Quantity: MustDecimal("2")},
+				{ID: "a2", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("2")},
+				{ID: "a3", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("4")},
+			},
+			wantPrice:  "101",
+			wantVolume: "5",
+			wantTrades: 4,
+		},
+		{
+			name: "minimum imbalance",
+			orders: []Order{
+				{ID: "b1", Side: Bid, Price: MustDecimal("101"), Quantity: MustDecimal("2")},
+				{ID: "b2", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")},
+				{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("2")},
+			},
+			wantPrice:  "101",
+			wantVolume: "2",
+			wantTrades: 1,
+		},
+		{
+			name:      "reference price",
+			lastPrice: "104",
+			orders: []Order{
+				{ID: "b1", Side: Bid, Price: MustDecimal("105"), Quantity: MustDecimal("2")},
+				{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("2")},
+			},
+			wantPrice:  "105",
+			wantVolume: "2",
+			wantTrades: 1,
+		},
+		{
+			name: "no cross",
+			orders: []Order{
+				{ID: "b1", Side: Bid, Price: MustDecimal("99"), Quantity: MustDecimal("1")},
+				{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1")},
+			},
+			wantPrice:  "0",
+			wantVolume: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook()
+			if tt.lastPrice != "" {
+				ob.lastPrice = MustDecimal(tt.lastPrice)
+			}
+			ob.StartAuction()
+			for _, order := range tt.orders {
+				report, err := ob.PlaceOrder(order)
+				if err != nil || len(report.Trades) != 0 {
+					t.Fatalf("PlaceOrder(%s) = %+v, %v, want resting without trades", order.ID, report, err)
+				}
+			}
+
+			indication, found := ob.IndicativePrice()
+			if found != (tt.wantTrades > 0) || indication.Price.String() != tt.wantPrice {
+				t.Errorf("IndicativePrice() = %+v, %v, want price %s", indication, found, tt.wantPrice)
+			}
+
+			result, err := ob.Uncross()
+			if err != nil {
+				t.Fatalf("Uncross() error = %v", err)
+			}
+			if result.Price.String() != tt.wantPrice || result.Volume.String() != tt.wantVolume || len(result.Trades) != tt.wantTrades {
+				t.Errorf("Uncross() = %+v, want %s x %s in %d trades", result, tt.wantVolume, tt.wantPrice, tt.wantTrades)
+			}
+			for _, trade := range result.Trades {
+				if !trade.Price.Equal(result.Price) {
+					t.Errorf("trade %d at %s, want auction price %s", trade.ID, trade.Price, result.Price)
+				}
+			}
+
+			bid, _, bidFound := ob.GetBestBid()
+			ask, _, askFound := ob.GetBestAsk()
+			if bidFound && askFound && !bid.LessThan(ask) {
+				t.Errorf("book still crossed after uncross: bid %s, ask %s", bid, ask)
+			}
+		})
+	}
+}
+
+func TestAuctionMode(t *testing.T) {
+	ob := NewOrderBook()
+
+	var indications []Event
+	ob.Subscribe(ListenerFunc(func(e Event) {
+		if e.Type == EventAuctionIndicative {
+			indications = append(indications, e)
+		}
+	}))
+
+	ob.StartAuction()
+	if _, err := ob.PlaceOrder(Order{ID: "m1", Side: Bid, Type: Market, Quantity: MustDecimal("1")}); err != ErrAuctionOrderType {
+		t.Errorf("market order error = %v, want ErrAuctionOrderType", err)
+	}
+	if _, err := ob.PlaceOrder(Order{ID: "i1", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1"), TimeInForce: IOC}); err != ErrAuctionOrderType {
+		t.Errorf("IOC order error = %v, want ErrAuctionOrderType", err)
+	}
+
+	ob.PlaceOrder(Order{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("2")})
+	ob.PlaceOrder(Order{ID: "b1", Side: Bid, Price: MustDecimal("101"), Quantity: MustDecimal("1"), PostOnly: PostOnlyReject})
+
+	last := indications[len(indications)-1]
+	if last.Price.String() != "100" || last.Quantity.String() != "1" || last.Remaining.String() != "1" || last.Side != Ask {
+		t.Errorf("last indication = %+v, want 1 at 100 with 1 ask surplus", last)
+	}
+
+	if _, err := ob.Uncross(); err != nil {
+		t.Fatalf("Uncross() error = %v", err)
+	}
+	if _, err := ob.Uncross(); err != ErrNoAuction {
+		t.Errorf("second Uncross() error = %v, want ErrNoAuction", err)
+	}
+
+	report, err := ob.PlaceOrder(Order{ID: "b2", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+	if err != nil || report.Status != StatusFilled {
+		t.Errorf("PlaceOrder() after uncross = %+v, %v, want filled continuously", report, err)
+	}
+}
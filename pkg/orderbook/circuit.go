@@ -0,0 +1,215 @@
+package orderbook
+
+import "time"
+
+// BandReference selects the price a price band is centered on.
+type BandReference int
+
+const (
+	BandLastPrice BandReference = iota // Last trade price
+	BandMidPrice                       // Midpoint of the best bid and ask, or the last price when a side is empty
+)
+
+// PriceBand rejects limit orders priced more than Percent (0.1 = 10%) away
+// from the reference price. Orders are not checked while there is no
+// reference.
+type PriceBand struct {
+	Percent   Decimal
+	Reference BandReference
+}
+
+// CircuitBreaker halts trading for Cooldown when the trade price moves more
+// than Move (0.1 = 10%) away from any price traded within the last Window.
+type CircuitBreaker struct {
+	Move     Decimal
+	Window   time.Duration
+	Cooldown time.Duration
+}
+
+// WithPriceBand rejects fat-finger limit orders on arrival and on amend.
+func WithPriceBand(band PriceBand) Option {
+	return func(ob *OrderBook) {
+		ob.band = band
+	}
+}
+
+// WithCircuitBreaker moves the book to SessionHalted after a sharp price move
+// and back to continuous trading once the cooldown has passed.
+func WithCircuitBreaker(breaker CircuitBreaker) Option {
+	return func(ob *OrderBook) {
+		ob.breaker = breaker
+	}
+}
+
+var half = NewDecimal(5, 1)
+
+// checkBand rejects a limit order outside the price band.
+func (ob *OrderBook) checkBand(order *Order) error {
+	if !ob.band.Percent.IsPositive() || order.Type != Limit {
+		return nil
+	}
+
+	reference := ob.lastPrice
+	if ob.band.Reference == BandMidPrice {
+		bid, bidFound := ob.bestPrice(Bid)
+		ask, askFound := ob.bestPrice(Ask)
+		if bidFound && askFound {
+			mid, err := bid.Add(ask).Mul(half)
+			if err != nil {
+				return err
+			}
+			reference = mid
+		}
+	}
+	if reference.IsZero() {
+		return nil
+	}
+
+	width, err := reference.Mul(ob.band.Percent)
+	if err != nil {
+		return err
+	}
+	if order.Price.LessThan(reference.Sub(width)) || order.Price.GreaterThan(reference.Add(width)) {
+		return ErrPriceOutsideBand
+	}
+	return nil
+}
+
+type pricePoint struct {
+	time  time.Time
+	price Decimal
+}
+
+// priceWindow tracks the lowest and highest trade price of a rolling window
+// with monotonic queues, so each trade is checked in amortized constant time.
+type priceWindow struct {
+	lows  []pricePoint // Increasing prices
+	highs []pricePoint // Decreasing prices
+}
+
+func (w *priceWindow) push(point pricePoint) {
+	for len(w.lows) > 0 && w.lows[len(w.lows)-1].price.Cmp(point.price) >= 0 {
+		w.lows = w.lows[:len(w.lows)-1]
+	}
+	w.lows = append(w.lows, point)
+
+	for len(w.highs) > 0 && w.highs[len(w.highs)-1].price.Cmp(point.price) <= 0 {
+		w.highs = w.highs[:len(w.highs)-1]
+	}
+	w.highs = append(w.highs, point)
+}
+
+// evict drops the points traded before since.
+func (w *priceWindow) evict(since time.Time) {
+	for len(w.lows) > 0 && w.lows[0].time.Before(since) {
+		w.lows = w.lows[1:]
+	}
+	for len(w.highs) > 0 && w.highs[0].time.Before(since) {
+		w.highs = w.highs[1:]
+	}
+}
+
+func (w *priceWindow) len() int {
+	return len(w.lows)
+}
+
+func (w *priceWindow) reset() {
+	w.lows, w.highs = nil, nil
+}
+
+// recordTrade feeds a trade price to the circuit breaker and halts the book if
+// it moved too far within the window.
+func (ob *OrderBook) recordTrade(price Decimal) {
+	if !ob.breaker.Move.IsPositive() || ob.session != SessionContinuous {
+		return
+	}
+
+	ob.window.evict(ob.now.Add(-ob.breaker.Window))
+	if ob.window.len() > 0 && ob.moved(price, ob.window.lows[0].price, ob.window.highs[0].price) {
+		ob.window.reset()
+		ob.haltedUntil = ob.now.Add(ob.breaker.Cooldown)
+		ob.transition(SessionHalted, ReasonCircuitBreaker, price)
+		return
+	}
+	ob.window.push(pricePoint{time: ob.now, price: price})
+}
+
+// moved reports whether price is more than the breaker's move away from low or
+// high, the extremes of the window.
+func (ob *OrderBook) moved(price, low, high Decimal) bool {
+
+	up, err := low.Mul(one.Add(ob.breaker.Move))
+	if err == nil && price.GreaterThan(up) {
+		return true
+	}
+	down, err := high.Mul(one.Sub(ob.breaker.Move))
+	return err == nil && price.LessThan(down)
+}
+
+// breakerProbe plays trades that have not happened yet against the circuit
+// breaker without touching its window, so a FOK order can tell up front
+// whether a halt would cut its sweep short.
+type breakerProbe struct {
+	ob        *OrderBook
+	low, high Decimal
+	found     bool // The window holds a price
+}
+
+func (ob *OrderBook) probeBreaker() *breakerProbe {
+	p := &breakerProbe{ob: ob}
+	since := ob.now.Add(-ob.breaker.Window)
+	for _, point := range ob.window.lows {
+		if !point.time.Before(since) {
+			p.low, p.found = point.price, true
+			break
+		}
+	}
+	for _, point := range ob.window.highs {
+		if !point.time.Before(since) {
+			p.high = point.price
+			break
+		}
+	}
+	return p
+}
+
+// trade reports whether a trade at price would halt the book, and records it
+// otherwise.
+func (p *breakerProbe) trade(price Decimal) bool {
+	if !p.ob.breaker.Move.IsPositive() || p.ob.session != SessionContinuous {
+		return false
+	}
+	if !p.found {
+		p.low, p.high, p.found = price, price, true
+		return false
+	}
+	if p.ob.moved(price, p.low, p.high) {
+		return true
+	}
+	p.low, p.high = minDecimal(p.low, price), maxDecimal(p.high, price)
+	return false
+}
+
+func (ob *OrderBook) halted() bool {
+	return ob.session == SessionHalted
+}
+
+// resume lifts a circuit breaker halt whose cooldown has passed. It runs at the
+// start of every write, so a halt ends with the first write after it.
+func (ob *OrderBook) resume() {
+	if ob.haltedUntil.IsZero() || ob.now.Before(ob.haltedUntil) {
+		return
+	}
+	ob.haltedUntil = time.Time{}
+	ob.transition(SessionContinuous, ReasonCooldown, Decimal{})
+}
+
+// HaltedUntil returns the end of the current circuit breaker halt, or the zero
+// time if the book is not halted by the breaker. A halt that has run out is
+// only lifted by the next write, so the returned time may lie in the past.
+func (ob *OrderBook) HaltedUntil() time.Time {
+	ob.RLock()
+	defer ob.RUnlock()
+
+	return ob.haltedUntil
+}
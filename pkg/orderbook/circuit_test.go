@@ -0,0 +1,130 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriceBand(t *testing.T) {
+	tests := []struct {
+		name      string
+		reference BandReference
+		price     string
+		want      error
+	}{
+		{"inside last price band", BandLastPrice, "109", nil},
+		{"above last price band", BandLastPrice, "111", ErrPriceOutsideBand},
+		{"below last price band", BandLastPrice, "89", ErrPriceOutsideBand},
+		{"inside mid band", BandMidPrice, "115", nil},
+		{"above mid band", BandMidPrice, "116", ErrPriceOutsideBand},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook(WithPriceBand(PriceBand{Percent: MustDecimal("0.1"), Reference: tt.reference}))
+			ob.PlaceOrder(Order{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+			ob.PlaceOrder(Order{ID: "b1", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+			ob.PlaceOrder(Order{ID: "b2", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+			ob.PlaceOrder(Order{ID: "a2", Side: Ask, Price: MustDecimal("110"), Quantity: MustDecimal("1")})
+
+			report, err := ob.PlaceOrder(Order{ID: "a3", Side: Ask, Price: MustDecimal(tt.price), Quantity: MustDecimal("1")})
+			if err != tt.want {
+				t.Fatalf("PlaceOrder() error = %v, want %v", err, tt.want)
+			}
+			if err != nil && report.Status != StatusRejected {
+				t.Errorf("Status = %v, want Rejected", report.Status)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	ob := NewOrderBook(WithCircuitBreaker(CircuitBreaker{
+		Move:     MustDecimal("0.05"),
+		Window:   time.Minute,
+		Cooldown: 5 * time.Minute,
+	}))
+
+	var halts, resumes int
+	ob.Subscribe(ListenerFunc(func(e Event) {
+		switch {
+		case e.Type == EventSessionChanged && e.Session == SessionHalted:
+			halts++
+		case e.Type == EventSessionChanged && e.Session == SessionContinuous:
+			resumes++
+		}
+	}))
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	place := func(sequence uint64, offset time.Duration, order Order) Result {
+		t.Helper()
+		results, err := Replay(ob, []Command{{Sequence: sequence, Timestamp: start.Add(offset), Type: CmdPlace, Order: order}})
+		if err != nil {
+			t.Fatalf("Replay() error = %v", err)
+		}
+		return results[0]
+	}
+
+	place(1, 0, Order{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+	place(2, 0, Order{ID: "a2", Side: Ask, Price: MustDecimal("104"), Quantity: MustDecimal("1")})
+	place(3, 0, Order{ID: "a3", Side: Ask, Price: MustDecimal("106"), Quantity: MustDecimal("1")})
+	place(4, 0, Order{ID: "a4", Side: Ask, Price: MustDecimal("107"), Quantity: MustDecimal("1")})
+
+	// 100 and 104 are within 5%; 106 trips the breaker and the sweep stops.
+	sweep := place(5, time.Second, Order{ID: "b1", Side: Bid, Price: MustDecimal("107"), Quantity: MustDecimal("4")})
+	if len(sweep.Report.Trades) != 3 || sweep.Report.Status != StatusCanceled {
+		t.Errorf("sweep report = %+v, want 3 trades and the remainder canceled", sweep.Report)
+	}
+	if halts != 1 || ob.HaltedUntil() != start.Add(time.Second+5*time.Minute) {
+		t.Errorf("halts = %d, HaltedUntil() = %v", halts, ob.HaltedUntil())
+	}
+
+	halted := place(6, time.Minute, Order{ID: "b2", Side: Bid, Price: MustDecimal("107"), Quantity: MustDecimal("1")})
+	if halted.Err != ErrTradingHalted {
+		t.Errorf("PlaceOrder() while halted error = %v, want ErrTradingHalted", halted.Err)
+	}
+
+	resumed := place(7, 10*time.Minute, Order{ID: "b3", Side: Bid, Price: MustDecimal("107"), Quantity: MustDecimal("1")})
+	if resumed.Err != nil || resumed.Report.Status != StatusFilled || resumes != 1 {
+		t.Errorf("PlaceOrder() after cooldown = %+v, resumes = %d", resumed, resumes)
+	}
+	if !ob.HaltedUntil().IsZero() {
+		t.Errorf("HaltedUntil() = %v, want zero after resume", ob.HaltedUntil())
+	}
+}
+
+func TestCircuitBreakerFillOrKill(t *testing.T) {
+	tests := []struct {
+		name       string
+		quantity   string
+		wantErr    error
+		wantTrades int
+	}{
+		// The trade at 120 trips the breaker, so 121 would never be reached.
+		{"halt cuts the sweep short", "2", ErrFillOrKill, 0},
+		// The tripping trade is the last one the order needs.
+		{"halt after the last fill", "1", nil, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook(WithCircuitBreaker(CircuitBreaker{
+				Move:     MustDecimal("0.05"),
+				Window:   time.Minute,
+				Cooldown: time.Minute,
+			}))
+			ob.PlaceOrder(Order{ID: "a0", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+			ob.PlaceOrder(Order{ID: "b0", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+			ob.PlaceOrder(Order{ID: "a1", Side: Ask, Price: MustDecimal("120"), Quantity: MustDecimal("1")})
+			ob.PlaceOrder(Order{ID: "a2", Side: Ask, Price: MustDecimal("121"), Quantity: MustDecimal("1")})
+
+			report, err := ob.PlaceOrder(Order{ID: "fok", Side: Bid, Price: MustDecimal("121"), Quantity: MustDecimal(tt.quantity), TimeInForce: FOK})
+			if err != tt.wantErr || len(report.Trades) != tt.wantTrades {
+				t.Fatalf("PlaceOrder(fok) = %+v, %v, want %d trades and error %v", report, err, tt.wantTrades, tt.wantErr)
+			}
+			if tt.wantErr != nil && ob.Session() != SessionContinuous {
+				t.Errorf("Session() = %v, want the book still trading", ob.Session())
+			}
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// MaxScale is the largest number of fractional digits a Decimal can carry.
+const MaxScale = 18
+
+var (
+	ErrInvalidDecimal  = errors.New("orderbook: invalid decimal")
+	ErrDecimalOverflow = errors.New("orderbook: decimal overflow")
+)
+
+var pow10 = func() [MaxScale + 1]int64 {
+	var table [MaxScale + 1]int64
+	table[0] = 1
+	for i := 1; i <= MaxScale; i++ {
+		table[i] = table[i-1] * 10
+	}
+	return table
+}()
+
+// Decimal is a fixed-point number stored as an int64 mantissa and a base-10
+// scale, so Decimal{mantissa: 11135112, scale: 2} is 111351.12. Arithmetic
+// aligns operands to the larger scale and never rounds.
+type Decimal struct {
+	mantissa int64
+	scale    uint8
+}
+
+func NewDecimal(mantissa int64, scale uint8) Decimal {
+	if scale > MaxScale {
+		panic(fmt.Sprintf("orderbook: decimal scale %d exceeds %d", scale, MaxScale))
+	}
+	return Decimal{mantissa: mantissa, scale: scale}
+}
+
+// ParseDecimal parses plain decimal notation such as "111351.12" or "-0.0001".
+// The scale is taken from the number of fractional digits, so the value
+// formats back to the same string.
+func ParseDecimal(s string) (Decimal, error) {
+	if s == "" {
+		return Decimal{}, fmt.Errorf("%w: empty string", ErrInvalidDecimal)
+	}
+
+	intPart, fracPart, hasDot := strings.Cut(s, ".")
+	if hasDot && fracPart == "" {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	if len(fracPart) > MaxScale {
+		return Decimal{}, fmt.Errorf("%w: %q has more than %d fractional digits", ErrInvalidDecimal, s, MaxScale)
+	}
+
+	digits := intPart + fracPart
+	if strings.ContainsAny(digits, "+eE_") {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	if strings.HasPrefix(fracPart, "-") {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+
+	mantissa, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	return Decimal{mantissa: mantissa, scale: uint8(len(fracPart))}, nil
+}
+
+// MustDecimal is like ParseDecimal but panics on malformed input. It is meant
+// for constants and tests.
+func MustDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) Mantissa() int64 {
+	return d.mantissa
+}
+
+func (d Decimal) Scale() uint8 {
+	return d.scale
+}
+
+func (d Decimal) String() string {
+	if d.scale == 0 {
+		return strconv.FormatInt(d.mantissa, 10)
+	}
+
+	sign := ""
+	m := d.mantissa
+	if m < 0 {
+		sign = "-"
+	}
+
+	digits := strconv.FormatUint(absUint64(m), 10)
+	if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Rescale returns d expressed with exactly scale fractional digits. It fails
+// if that would drop non-zero digits, which is how an order with more
+// precision than its symbol allows gets caught.
+func (d Decimal) Rescale(scale uint8) (Decimal, error) {
+	if scale > MaxScale {
+		return Decimal{}, fmt.Errorf("%w: scale %d exceeds %d", ErrInvalidDecimal, scale, MaxScale)
+	}
+	if scale >= d.scale {
+		mantissa, ok := scaleUp(d.mantissa, scale-d.scale)
+		if !ok {
+			return Decimal{}, fmt.Errorf("%w: %s at %d decimal places", ErrDecimalOverflow, d, scale)
+		}
+		return Decimal{mantissa: mantissa, scale: scale}, nil
+	}
+
+	factor := pow10[d.scale-scale]
+	if d.mantissa%factor != 0 {
+		return Decimal{}, fmt.Errorf("%w: %s does not fit in %d decimal places", ErrInvalidDecimal, d, scale)
+	}
+	return Decimal{mantissa: d.mantissa / factor, scale: scale}, nil
+}
+
+func (d Decimal) IsZero() bool {
+	return d.mantissa == 0
+}
+
+func (d Decimal) IsPositive() bool {
+	return d.mantissa > 0
+}
+
+func (d Decimal) Sign() int {
+	switch {
+	case d.mantissa > 0:
+		return 1
+	case d.mantissa < 0:
+		return -1
+	}
+	return 0
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{mantissa: -d.mantissa, scale: d.scale}
+}
+
+// Add panics with ErrDecimalOverflow if the exact sum does not fit.
+func (d Decimal) Add(o Decimal) Decimal {
+	sum, ok := add(d, o)
+	if !ok {
+		panic(fmt.Errorf("%w: %s + %s", ErrDecimalOverflow, d, o))
+	}
+	return sum
+}
+
+// add is Add for totals of unbounded length; it reports false instead of
+// panicking.
+func add(d, o Decimal) (Decimal, bool) {
+	a, b, scale, ok := alignExact(d, o)
+	if !ok {
+		if a, b, scale, ok = alignExact(d.normalize(), o.normalize()); !ok {
+			return Decimal{}, false
+		}
+	}
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return Decimal{}, false
+	}
+	return Decimal{mantissa: sum, scale: scale}, true
+}
+
+// Sub panics with ErrDecimalOverflow if the exact difference does not fit.
+func (d Decimal) Sub(o Decimal) Decimal {
+	a, b, scale := align(d, o)
+	diff := a - b
+	if (b > 0 && diff > a) || (b < 0 && diff < a) {
+		panic(fmt.Errorf("%w: %s - %s", ErrDecimalOverflow, d, o))
+	}
+	return Decimal{mantissa: diff, scale: scale}
+}
+
+// Mul returns the exact product. Its scale is the sum of both scales, less any
+// trailing zeros needed to stay within MaxScale. If that product does not fit,
+// trailing zeros of the operands are dropped first, so 40000.00000000 *
+// 1.00000000 still multiplies.
+func (d Decimal) Mul(o Decimal) (Decimal, error) {
+	if product, ok := mul(d, o); ok {
+		return product, nil
+	}
+	if product, ok := mul(d.normalize(), o.normalize()); ok {
+		return product, nil
+	}
+	return Decimal{}, fmt.Errorf("%w: %s * %s", ErrDecimalOverflow, d, o)
+}
+
+func mul(d, o Decimal) (Decimal, bool) {
+	hi, lo := bits.Mul64(absUint64(d.mantissa), absUint64(o.mantissa))
+	if hi != 0 || lo > math.MaxInt64 {
+		return Decimal{}, false
+	}
+
+	mantissa := int64(lo)
+	if (d.mantissa < 0) != (o.mantissa < 0) {
+		mantissa = -mantissa
+	}
+
+	scale := int(d.scale) + int(o.scale)
+	for scale > MaxScale && mantissa%10 == 0 {
+		mantissa /= 10
+		scale--
+	}
+	if scale > MaxScale {
+		return Decimal{}, false
+	}
+	return Decimal{mantissa: mantissa, scale: uint8(scale)}, true
+}
+
+// Cmp compares exactly, even when one operand does not fit at the scale of the
+// other.
+func (d Decimal) Cmp(o Decimal) int {
+	a, b, _, ok := alignExact(d, o)
+	if !ok {
+		// Only the operand with the smaller scale is scaled up, and it overflows
+		// because it is larger in magnitude than the other one.
+		if d.scale < o.scale {
+			return d.Sign()
+		}
+		return -o.Sign()
+	}
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (d Decimal) Equal(o Decimal) bool {
+	return d.Cmp(o) == 0
+}
+
+func (d Decimal) LessThan(o Decimal) bool {
+	return d.Cmp(o) < 0
+}
+
+func (d Decimal) GreaterThan(o Decimal) bool {
+	return d.Cmp(o) > 0
+}
+
+// IsMultipleOf reports whether d is a whole multiple of a positive step, as in
+// a price on the tick grid.
+func (d Decimal) IsMultipleOf(step Decimal) bool {
+	if !step.IsPositive() {
+		return false
+	}
+	if d.scale >= step.scale {
+		s, ok := scaleUp(step.mantissa, d.scale-step.scale)
+		if !ok {
+			// The step is larger than d.
+			return d.IsZero()
+		}
+		return d.mantissa%s == 0
+	}
+
+	// d * 10^k can exceed an int64, so take the remainder of the 128-bit product.
+	hi, lo := bits.Mul64(absUint64(d.mantissa), uint64(pow10[step.scale-d.scale]))
+	return bits.Rem64(hi, lo, uint64(step.mantissa)) == 0
+}
+
+// normalize drops trailing fractional zeros.
+func (d Decimal) normalize() Decimal {
+	for d.scale > 0 && d.mantissa%10 == 0 {
+		d.mantissa /= 10
+		d.scale--
+	}
+	return d
+}
+
+// roundToTick rounds a positive price down, or up if up is set, to a multiple
+// of tick. A zero tick leaves the price as is.
+func roundToTick(price, tick Decimal, up bool) Decimal {
+	if !tick.IsPositive() {
+		return price
+	}
+	p, t, scale := align(price, tick)
+	rounded := p - p%t
+	if up && rounded != p {
+		rounded += t
+	}
+	return Decimal{mantissa: rounded, scale: scale}
+}
+
+func minDecimal(a, b Decimal) Decimal {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+// align expresses both operands at a common scale for arithmetic. If the
+// larger scale does not fit, trailing zeros are dropped to find a smaller one;
+// if that fails too it panics with ErrDecimalOverflow rather than wrap around.
+func align(a, b Decimal) (int64, int64, uint8) {
+	if x, y, scale, ok := alignExact(a, b); ok {
+		return x, y, scale
+	}
+	if x, y, scale, ok := alignExact(a.normalize(), b.normalize()); ok {
+		return x, y, scale
+	}
+	panic(fmt.Errorf("%w: %s and %s have no common scale", ErrDecimalOverflow, a, b))
+}
+
+// alignExact expresses both operands at the larger of their scales. It reports
+// false if the operand scaled up does not fit in an int64.
+func alignExact(a, b Decimal) (int64, int64, uint8, bool) {
+	switch {
+	case a.scale == b.scale:
+		return a.mantissa, b.mantissa, a.scale, true
+	case a.scale > b.scale:
+		y, ok := scaleUp(b.mantissa, a.scale-b.scale)
+		return a.mantissa, y, a.scale, ok
+	default:
+		x, ok := scaleUp(a.mantissa, b.scale-a.scale)
+		return x, b.mantissa, b.scale, ok
+	}
+}
+
+// scaleUp returns m * 10^k and reports false if it does not fit in an int64.
+func scaleUp(m int64, k uint8) (int64, bool) {
+	hi, lo := bits.Mul64(absUint64(m), uint64(pow10[k]))
+	if hi != 0 || lo > math.MaxInt64 {
+		return 0, false
+	}
+	if m < 0 {
+		return -int64(lo), true
+	}
+	return int64(lo), true
+}
+
+func maxDecimal(a, b Decimal) Decimal {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+func absUint64(v int64) uint64 {
+	if v < 0 {
+		return uint64(-v)
+	}
+	return uint64(v)
+}
@@ -0,0 +1,172 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "integer", input: "100", want: "100"},
+		{name: "binance_price", input: "111351.12", want: "111351.12"},
+		{name: "binance_quantity", input: "0.0001", want: "0.0001"},
+		{name: "trailing_zeros_kept", input: "1.500", want: "1.500"},
+		{name: "negative", input: "-0.05", want: "-0.05"},
+		{name: "leading_dot", input: ".5", want: "0.5"},
+		{name: "empty", input: "", wantErr: true},
+		{name: "trailing_dot", input: "1.", wantErr: true},
+		{name: "exponent", input: "1e5", wantErr: true},
+		{name: "garbage", input: "abc", wantErr: true},
+		{name: "too_precise", input: "0.1234567890123456789", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDecimal(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDecimal(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidDecimal) {
+					t.Errorf("ParseDecimal(%q) error = %v, want ErrInvalidDecimal", tt.input, err)
+				}
+				return
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseDecimal(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	sum := MustDecimal("0.1").Add(MustDecimal("0.2"))
+	if !sum.Equal(MustDecimal("0.3")) {
+		t.Errorf("0.1 + 0.2 = %s, want 0.3", sum)
+	}
+
+	if MustDecimal("1.10").Cmp(MustDecimal("1.1")) != 0 {
+		t.Errorf("1.10 and 1.1 should compare equal")
+	}
+
+	diff := MustDecimal("0.0003").Sub(MustDecimal("0.0001")).Sub(MustDecimal("0.0002"))
+	if !diff.IsZero() {
+		t.Errorf("0.0003 - 0.0001 - 0.0002 = %s, want 0", diff)
+	}
+
+	if _, err := MustDecimal("1.25").Rescale(1); err == nil {
+		t.Errorf("Rescale(1) of 1.25 should fail")
+	}
+	if d, err := MustDecimal("1.2").Rescale(4); err != nil || d.String() != "1.2000" {
+		t.Errorf("Rescale(4) of 1.2 = %s, %v, want 1.2000", d, err)
+	}
+}
+
+func TestDecimalOverflow(t *testing.T) {
+	ten, tiny := MustDecimal("10"), MustDecimal("0.000000000000000001")
+
+	cmps := []struct {
+		a, b Decimal
+		want int
+	}{
+		{ten, tiny, 1},
+		{tiny, ten, -1},
+		{ten.Neg(), tiny, -1},
+		{tiny, ten.Neg(), 1},
+		{MustDecimal("9223372036854775807"), MustDecimal("0.5"), 1},
+	}
+	for _, tt := range cmps {
+		if got := tt.a.Cmp(tt.b); got != tt.want {
+			t.Errorf("%s.Cmp(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	multiples := []struct {
+		d, step Decimal
+		want    bool
+	}{
+		{ten, tiny, true},
+		{ten, MustDecimal("0.000000000000000003"), false},
+		{MustDecimal("30"), MustDecimal("0.000000000000000003"), true},
+		{MustDecimal("0.5"), MustDecimal("9223372036854775807"), false},
+		{Decimal{}, MustDecimal("9223372036854775807"), true},
+	}
+	for _, tt := range multiples {
+		if got := tt.d.IsMultipleOf(tt.step); got != tt.want {
+			t.Errorf("%s.IsMultipleOf(%s) = %v, want %v", tt.d, tt.step, got, tt.want)
+		}
+	}
+
+	// Trailing zeros make room for the other operand.
+	if got := MustDecimal("1.000000000000000000").Add(ten); !got.Equal(MustDecimal("11")) {
+		t.Errorf("1.000000000000000000 + 10 = %s, want 11", got)
+	}
+	if got, err := MustDecimal("40000.00000000").Mul(MustDecimal("1.00000000")); err != nil || !got.Equal(MustDecimal("40000")) {
+		t.Errorf("40000.00000000 * 1.00000000 = %s, %v, want 40000", got, err)
+	}
+
+	if _, err := MustDecimal("922.12345678").Mul(MustDecimal("100.12345678")); !errors.Is(err, ErrDecimalOverflow) {
+		t.Errorf("Mul() error = %v, want ErrDecimalOverflow", err)
+	}
+	if _, err := ten.Rescale(18); !errors.Is(err, ErrDecimalOverflow) {
+		t.Errorf("Rescale(18) error = %v, want ErrDecimalOverflow", err)
+	}
+
+	panics := []struct {
+		name string
+		op   func() Decimal
+	}{
+		{"add without common scale", func() Decimal { return ten.Add(tiny) }},
+		{"sub without common scale", func() Decimal { return ten.Sub(tiny) }},
+		{"add", func() Decimal { return MustDecimal("9223372036854775807").Add(MustDecimal("1")) }},
+		{"sub", func() Decimal { return MustDecimal("-9223372036854775807").Sub(MustDecimal("2")) }},
+	}
+	for _, tt := range panics {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrDecimalOverflow) {
+					t.Errorf("recovered %v, want ErrDecimalOverflow", err)
+				}
+			}()
+			got := tt.op()
+			t.Errorf("got %s, want a panic", got)
+		})
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var payload struct {
+		Price    Decimal `json:"price"`
+		Quantity Decimal `json:"quantity"`
+	}
+
+	in := `{"price":"111351.12","quantity":"0.0001"}`
+	if err := json.Unmarshal([]byte(in), &payload); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	out, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(out) != in {
+		t.Errorf("round trip = %s, want %s", out, in)
+	}
+}
+
+func TestOrderBookMergesEquivalentPriceLevels(t *testing.T) {
+	ob := NewOrderBook()
+	ob.InsertOrder(Order{ID: "a1", Side: Ask, Price: MustDecimal("0.3"), Quantity: MustDecimal("1")})
+	ob.InsertOrder(Order{ID: "a2", Side: Ask, Price: MustDecimal("0.1").Add(MustDecimal("0.2")), Quantity: MustDecimal("1")})
+
+	if ob.Asks.Size() != 1 {
+		t.Errorf("got %d ask levels, want 1", ob.Asks.Size())
+	}
+}
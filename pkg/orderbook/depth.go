@@ -0,0 +1,53 @@
+package orderbook
+
+import (
+	"container/list"
+
+	"github.com/emirpasic/gods/v2/trees/redblacktree"
+)
+
+// PriceLevel is the aggregated view of one price in the book. Iceberg orders
+// only contribute their displayed quantity.
+type PriceLevel struct {
+	Price    Decimal `json:"price"`
+	Quantity Decimal `json:"quantity"`
+	Orders   int     `json:"orders"`
+}
+
+type Depth struct {
+	Bids []PriceLevel `json:"bids"` // Best (highest) price first
+	Asks []PriceLevel `json:"asks"` // Best (lowest) price first
+}
+
+// Depth returns up to n aggregated price levels per side, best first. A
+// non-positive n returns every level.
+func (ob *OrderBook) Depth(n int) Depth {
+	ob.RLock()
+	defer ob.RUnlock()
+
+	return Depth{
+		Bids: levels(ob.Bids, n),
+		Asks: levels(ob.Asks, n),
+	}
+}
+
+func levels(tree *redblacktree.Tree[Decimal, *list.List], n int) []PriceLevel {
+	size := tree.Size()
+	if n > 0 && n < size {
+		size = n
+	}
+
+	result := make([]PriceLevel, 0, size)
+	for iter := tree.Iterator(); iter.Next() && len(result) < size; {
+		result = append(result, aggregate(iter.Key(), iter.Value()))
+	}
+	return result
+}
+
+func aggregate(price Decimal, queue *list.List) PriceLevel {
+	level := PriceLevel{Price: price, Orders: queue.Len()}
+	for e := queue.Front(); e != nil; e = e.Next() {
+		level.Quantity = level.Quantity.Add(e.Value.(*Order).displayed())
+	}
+	return level
+}
@@ -0,0 +1,74 @@
+package orderbook
+
+import "testing"
+
+func TestDepth(t *testing.T) {
+	ob := NewOrderBook()
+	for _, order := range []Order{
+		{ID: "b1", Side: Bid, Price: MustDecimal("99"), Quantity: MustDecimal("1")},
+		{ID: "b2", Side: Bid, Price: MustDecimal("99"), Quantity: MustDecimal("2")},
+		{ID: "b3", Side: Bid, Price: MustDecimal("98"), Quantity: MustDecimal("4")},
+		{ID: "b4", Side: Bid, Price: MustDecimal("97"), Quantity: MustDecimal("1")},
+		{ID: "a1", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("10"), DisplayQuantity: MustDecimal("1")},
+		{ID: "a2", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("0.5")},
+	} {
+		if _, err := ob.PlaceOrder(order); err != nil {
+			t.Fatalf("PlaceOrder(%s) error = %v", order.ID, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		n        int
+		wantBids []PriceLevel
+		wantAsks []PriceLevel
+	}{
+		{
+			name: "top_two",
+			n:    2,
+			wantBids: []PriceLevel{
+				{Price: MustDecimal("99"), Quantity: MustDecimal("3"), Orders: 2},
+				{Price: MustDecimal("98"), Quantity: MustDecimal("4"), Orders: 1},
+			},
+			wantAsks: []PriceLevel{
+				{Price: MustDecimal("101"), Quantity: MustDecimal("1.5"), Orders: 2},
+			},
+		},
+		{
+			name: "all_levels",
+			n:    0,
+			wantBids: []PriceLevel{
+				{Price: MustDecimal("99"), Quantity: MustDecimal("3"), Orders: 2},
+				{Price: MustDecimal("98"), Quantity: MustDecimal("4"), Orders: 1},
+				{Price: MustDecimal("97"), Quantity: MustDecimal("1"), Orders: 1},
+			},
+			wantAsks: []PriceLevel{
+				{Price: MustDecimal("101"), Quantity: MustDecimal("1.5"), Orders: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			depth := ob.Depth(tt.n)
+			compareLevels(t, "bids", depth.Bids, tt.wantBids)
+			compareLevels(t, "asks", depth.Asks, tt.wantAsks)
+		})
+	}
+
+	if price, qty, _ := ob.GetBestBid(); price.String() != "99" || qty.String() != "3" {
+		t.Errorf("GetBestBid() = %s x %s, want 99 x 3", price, qty)
+	}
+}
+
+func compareLevels(t *testing.T, side string, got, want []PriceLevel) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %d levels, want %d", side, len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].Price.Equal(want[i].Price) || !got[i].Quantity.Equal(want[i].Quantity) || got[i].Orders != want[i].Orders {
+			t.Errorf("%s[%d] = %+v, want %+v", side, i, got[i], want[i])
+		}
+	}
+}
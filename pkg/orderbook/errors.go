@@ -0,0 +1,40 @@
+package orderbook
+
+import "errors"
+
+var (
+	ErrInvalidSide            = errors.New("orderbook: unknown order side")
+	ErrInvalidPrice           = errors.New("orderbook: price must be positive")
+	ErrInvalidQuantity        = errors.New("orderbook: quantity must be positive")
+	ErrInvalidSlippage        = errors.New("orderbook: slippage must not be negative")
+	ErrInvalidOrderType       = errors.New("orderbook: unknown order type")
+	ErrInvalidTimeInForce     = errors.New("orderbook: unknown time in force")
+	ErrInvalidExpireTime      = errors.New("orderbook: GTD order needs an expire time in the future")
+	ErrFillOrKill             = errors.New("orderbook: FOK order cannot be filled in full")
+	ErrInvalidPostOnly        = errors.New("orderbook: post-only requires a GTC or GTD limit order")
+	ErrPostOnlyWouldCross     = errors.New("orderbook: post-only order would take liquidity")
+	ErrInvalidStopPrice       = errors.New("orderbook: stop price must be positive")
+	ErrInvalidDisplayQuantity = errors.New("orderbook: display quantity must be positive and not exceed quantity of a limit order")
+	ErrOrderNotFound          = errors.New("orderbook: order not found")
+	ErrInvalidEventType       = errors.New("orderbook: unknown event type")
+	ErrInvalidCommand         = errors.New("orderbook: unknown command")
+	ErrSequencerStopped       = errors.New("orderbook: sequencer stopped")
+	ErrSequenceGap            = errors.New("orderbook: command sequence does not follow the book")
+	ErrDuplicateOrder         = errors.New("orderbook: duplicate order ID")
+	ErrPriceOutsideBand       = errors.New("orderbook: price outside the allowed band")
+	ErrTradingHalted          = errors.New("orderbook: trading halted")
+	ErrAuctionOrderType       = errors.New("orderbook: only GTC and GTD limit orders are accepted during an auction")
+	ErrNoAuction              = errors.New("orderbook: book is not in an auction")
+	ErrMarketClosed           = errors.New("orderbook: market closed")
+	ErrInvalidSessionState    = errors.New("orderbook: unknown session state")
+	ErrInvalidTransition      = errors.New("orderbook: session transition not allowed")
+	ErrInvalidTimeOfDay       = errors.New("orderbook: time of day must be HH:MM or HH:MM:SS")
+	ErrInvalidOrderList       = errors.New("orderbook: order list needs an ID and two or more unpegged resting limit or untriggered stop orders on one side")
+	ErrDuplicateOrderList     = errors.New("orderbook: duplicate order list ID")
+	ErrInvalidListStatus      = errors.New("orderbook: unknown order list status")
+	ErrInvalidPeg             = errors.New("orderbook: pegged order must be a limit order without a fixed price")
+	ErrNoPegReference         = errors.New("orderbook: pegged order has no reference price")
+	ErrInvalidTrailing        = errors.New("orderbook: trailing stop needs either a positive amount or a percentage below 100%")
+	ErrOutOfRange             = errors.New("orderbook: prices and quantities must not exceed 1000000000 or carry more than 9 decimal places")
+	ErrLevelFull              = errors.New("orderbook: price level cannot hold more quantity")
+)
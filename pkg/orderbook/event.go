@@ -0,0 +1,215 @@
+package orderbook
+
+import "time"
+
+type EventType int
+
+const (
+	EventOrderAccepted EventType = iota
+	EventOrderRejected
+	EventOrderPartiallyFilled
+	EventOrderFilled
+	EventOrderCanceled
+	EventOrderExpired
+	EventOrderAmended
+	EventBookLevelChanged
+	EventSessionChanged
+	EventAuctionIndicative
+	EventAuctionUncrossed
+	EventListStatus
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventOrderAccepted:
+		return "OrderAccepted"
+	case EventOrderRejected:
+		return "OrderRejected"
+	case EventOrderPartiallyFilled:
+		return "OrderPartiallyFilled"
+	case EventOrderFilled:
+		return "OrderFilled"
+	case EventOrderCanceled:
+		return "OrderCanceled"
+	case EventOrderExpired:
+		return "OrderExpired"
+	case EventOrderAmended:
+		return "OrderAmended"
+	case EventBookLevelChanged:
+		return "BookLevelChanged"
+	case EventSessionChanged:
+		return "SessionChanged"
+	case EventAuctionIndicative:
+		return "AuctionIndicative"
+	case EventAuctionUncrossed:
+		return "AuctionUncrossed"
+	case EventListStatus:
+		return "ListStatus"
+	}
+	return "Unknown"
+}
+
+func (t EventType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *EventType) UnmarshalText(text []byte) error {
+	for typ := EventOrderAccepted; typ <= EventListStatus; typ++ {
+		if typ.String() == string(text) {
+			*t = typ
+			return nil
+		}
+	}
+	return ErrInvalidEventType
+}
+
+// Reasons carried by OrderCanceled and SessionChanged events. Rejections carry
+// the error text instead.
+const (
+	ReasonRequested      = "requested"
+	ReasonRemainder      = "unfilled remainder"
+	ReasonSelfTrade      = "self-trade prevention"
+	ReasonMassCancel     = "mass cancel"
+	ReasonCircuitBreaker = "circuit breaker"
+	ReasonCooldown       = "cooldown elapsed"
+	ReasonOrderList      = "order list contingency"
+	ReasonRepeg          = "pegged price moved"
+)
+
+// Event is one entry of a book's event stream. Sequence numbers are assigned
+// per book, start at 1 and have no gaps.
+type Event struct {
+	Sequence  uint64    `json:"sequence"`
+	Timestamp time.Time `json:"timestamp"`
+	Type      EventType `json:"type"`
+
+	OrderID    string       `json:"orderId,omitempty"`
+	AccountID  string       `json:"accountId,omitempty"`
+	ListID     string       `json:"listId,omitempty"`
+	Side       OrderSide    `json:"side"`
+	Price      Decimal      `json:"price"`            // Order, level, auction or halt-triggering trade price
+	Quantity   Decimal      `json:"quantity"`         // Fill quantity, total level quantity or auction volume
+	Remaining  Decimal      `json:"remaining"`        // Left on the order, or the auction imbalance
+	Orders     int          `json:"orders,omitempty"` // Orders at the level for BookLevelChanged
+	Trade      *Trade       `json:"trade,omitempty"`
+	Session    SessionState `json:"session"`    // New state for SessionChanged
+	ListStatus ListStatus   `json:"listStatus"` // New status for ListStatus
+	Reason     string       `json:"reason,omitempty"`
+}
+
+type Listener interface {
+	OnEvent(Event)
+}
+
+type ListenerFunc func(Event)
+
+func (f ListenerFunc) OnEvent(e Event) {
+	f(e)
+}
+
+type subscription struct {
+	id       uint64
+	listener Listener
+}
+
+// Subscribe registers a listener for every event of the book and returns a
+// function that removes it. Listeners are called synchronously, in sequence
+// order, by the goroutine writing to the book while it is locked, so they must
+// not call back into the book. Subscribe before starting a Sequencer.
+func (ob *OrderBook) Subscribe(listener Listener) func() {
+	ob.Lock()
+	defer ob.Unlock()
+
+	ob.subscriptionID++
+	id := ob.subscriptionID
+	ob.listeners = append(ob.listeners, subscription{id: id, listener: listener})
+
+	return func() {
+		ob.Lock()
+		defer ob.Unlock()
+
+		for i, s := range ob.listeners {
+			if s.id == id {
+				ob.listeners = append(ob.listeners[:i:i], ob.listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (ob *OrderBook) emit(event Event) {
+	ob.eventSequence++
+	if len(ob.listeners) == 0 {
+		return
+	}
+
+	event.Sequence = ob.eventSequence
+	event.Timestamp = ob.now
+	for _, s := range ob.listeners {
+		s.listener.OnEvent(event)
+	}
+}
+
+func (ob *OrderBook) emitOrder(typ EventType, order *Order, reason string) {
+	ob.emit(Event{
+		Type:      typ,
+		OrderID:   order.ID,
+		AccountID: order.AccountID,
+		ListID:    order.ListID,
+		Side:      order.Side,
+		Price:     order.Price,
+		Quantity:  order.Quantity,
+		Remaining: order.Quantity,
+		Reason:    reason,
+	})
+	// A rejected order never joined its list, see PlaceOrderList.
+	if order.ListID != "" && typ != EventOrderAccepted && typ != EventOrderAmended && typ != EventOrderRejected {
+		ob.settleList(order, true)
+	}
+}
+
+func (ob *OrderBook) emitFill(order *Order, trade *Trade) {
+	typ := EventOrderPartiallyFilled
+	if order.Quantity.IsZero() {
+		typ = EventOrderFilled
+	}
+	ob.emit(Event{
+		Type:      typ,
+		OrderID:   order.ID,
+		AccountID: order.AccountID,
+		Side:      order.Side,
+		Price:     trade.Price,
+		Quantity:  trade.Quantity,
+		Remaining: order.Quantity,
+		Trade:     trade,
+		ListID:    order.ListID,
+	})
+	if order.ListID != "" {
+		ob.settleList(order, order.Quantity.IsZero())
+	}
+}
+
+// emitLevel publishes the new aggregate of a price level. A removed level is
+// reported with zero quantity and no orders. During an auction it is followed
+// by the new indication.
+func (ob *OrderBook) emitLevel(side OrderSide, price Decimal) {
+	if ob.session == SessionPreOpen {
+		defer ob.emitIndication()
+	}
+	if len(ob.listeners) == 0 {
+		ob.eventSequence++
+		return
+	}
+
+	level := PriceLevel{Price: price}
+	if queue, found := ob.tree(side).Get(price); found {
+		level = aggregate(price, queue)
+	}
+	ob.emit(Event{
+		Type:     EventBookLevelChanged,
+		Side:     side,
+		Price:    level.Price,
+		Quantity: level.Quantity,
+		Orders:   level.Orders,
+	})
+}
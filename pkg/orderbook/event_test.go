@@ -0,0 +1,107 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEventStream(t *testing.T) {
+	ob := NewOrderBook()
+
+	var events []Event
+	unsubscribe := ob.Subscribe(ListenerFunc(func(e Event) {
+		events = append(events, e)
+	}))
+
+	steps := []func(){
+		func() {
+			ob.PlaceOrder(Order{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("2")})
+		},
+		func() {
+			ob.PlaceOrder(Order{ID: "b1", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+		},
+		func() { ob.AmendOrder("a1", Decimal{}, MustDecimal("0.5")) },
+		func() {
+			ob.PlaceOrder(Order{ID: "b2", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1"), TimeInForce: IOC})
+		},
+		func() {
+			ob.PlaceOrder(Order{ID: "b3", Side: Bid, Price: MustDecimal("99"), Quantity: MustDecimal("1")})
+		},
+		func() { ob.CancelOrder("b3") },
+		func() { ob.PlaceOrder(Order{ID: "b4", Side: Bid, Quantity: MustDecimal("1")}) },
+	}
+	for _, step := range steps {
+		step()
+	}
+
+	want := []struct {
+		typ     EventType
+		orderID string
+	}{
+		{EventOrderAccepted, "a1"},
+		{EventBookLevelChanged, ""},
+		{EventOrderAccepted, "b1"},
+		{EventOrderFilled, "b1"},
+		{EventOrderPartiallyFilled, "a1"},
+		{EventBookLevelChanged, ""},
+		{EventOrderAmended, "a1"},
+		{EventBookLevelChanged, ""},
+		{EventOrderAccepted, "b2"},
+		{EventOrderPartiallyFilled, "b2"},
+		{EventOrderFilled, "a1"},
+		{EventBookLevelChanged, ""},
+		{EventOrderCanceled, "b2"},
+		{EventOrderAccepted, "b3"},
+		{EventBookLevelChanged, ""},
+		{EventBookLevelChanged, ""},
+		{EventOrderCanceled, "b3"},
+		{EventOrderRejected, "b4"},
+	}
+
+	if len(events) != len(want) {
+		for _, e := range events {
+			t.Logf("%d %s %s", e.Sequence, e.Type, e.OrderID)
+		}
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i, w := range want {
+		got := events[i]
+		if got.Sequence != uint64(i+1) {
+			t.Errorf("event %d has sequence %d", i, got.Sequence)
+		}
+		if got.Type != w.typ || got.OrderID != w.orderID {
+			t.Errorf("event %d = %s %s, want %s %s", i, got.Type, got.OrderID, w.typ, w.orderID)
+		}
+		if got.Timestamp.IsZero() {
+			t.Errorf("event %d has no timestamp", i)
+		}
+	}
+
+	if removed := events[11]; !removed.Quantity.IsZero() || removed.Orders != 0 || removed.Side != Ask {
+		t.Errorf("removed level event = %+v, want empty ask level", removed)
+	}
+	if events[12].Reason != ReasonRemainder || events[17].Reason != ErrInvalidPrice.Error() {
+		t.Errorf("reasons = %q, %q", events[12].Reason, events[17].Reason)
+	}
+
+	raw, err := json.Marshal(events[3])
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded Event
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("Unmarshal(%s) error = %v", raw, err)
+	}
+	if decoded.Type != EventOrderFilled || decoded.Side != Bid {
+		t.Errorf("round trip = %+v", decoded)
+	}
+
+	unsubscribe()
+	ob.CancelAll()
+	if len(events) != len(want) {
+		t.Errorf("received events after unsubscribing")
+	}
+	if snapshot := ob.Snapshot(); snapshot.EventSequence != uint64(len(want)) {
+		t.Errorf("EventSequence = %d, want %d", snapshot.EventSequence, len(want))
+	}
+}
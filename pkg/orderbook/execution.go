@@ -0,0 +1,475 @@
+package orderbook
+
+import (
+	"container/list"
+	"math"
+	"time"
+)
+
+type OrderStatus int
+
+const (
+	StatusNew OrderStatus = iota
+	StatusPartiallyFilled
+	StatusFilled
+	StatusCanceled
+	StatusRejected
+	StatusExpired
+)
+
+func (s OrderStatus) String() string {
+	switch s {
+	case StatusNew:
+		return "New"
+	case StatusPartiallyFilled:
+		return "PartiallyFilled"
+	case StatusFilled:
+		return "Filled"
+	case StatusCanceled:
+		return "Canceled"
+	case StatusRejected:
+		return "Rejected"
+	case StatusExpired:
+		return "Expired"
+	}
+	return "Unknown"
+}
+
+type ExecutionReport struct {
+	OrderID string
+	Status  OrderStatus
+	Price   Decimal // Limit price the order was accepted at
+
+	Trades            []Trade
+	FilledQuantity    Decimal
+	RemainingQuantity Decimal
+
+	// SelfTradePrevented lists quantity removed from this order and from
+	// resting orders of the same account instead of trading.
+	SelfTradePrevented []SelfTradeEvent
+
+	// Triggered holds the reports of stop orders activated by this order's
+	// trades, including cascades, in activation order. Stops activated by
+	// repegged orders trading afterwards are only reported through events.
+	Triggered []ExecutionReport
+}
+
+// PlaceOrder matches the order against the opposite side as a taker and rests
+// whatever is left. Matching and resting happen under a single lock, so no
+// other caller can observe the order half-processed. Market and IOC orders
+// never rest: their unfilled remainder is canceled. FOK orders are rejected
+// without touching the book unless they can be filled in full. Stop orders are
+// held dormant until a trade reaches their stop price.
+func (ob *OrderBook) PlaceOrder(order Order) (ExecutionReport, error) {
+	ob.Lock()
+	defer ob.Unlock()
+	ob.begin(time.Now())
+
+	return ob.placeOrder(order)
+}
+
+func (ob *OrderBook) placeOrder(order Order) (ExecutionReport, error) {
+	defer ob.repeg()
+
+	// Only PlaceOrderList links orders.
+	order.ListID = ""
+	if order.Timestamp.IsZero() {
+		order.Timestamp = ob.now
+	}
+	if err := validate(&order); err != nil {
+		return ob.reject(&order, err)
+	}
+	if ob.exists(order.ID) {
+		return ob.reject(&order, ErrDuplicateOrder)
+	}
+	if err := ob.session.admits(); err != nil {
+		return ob.reject(&order, err)
+	}
+	if order.pegged() {
+		price, found := ob.pegPrice(&order)
+		if !found {
+			return ob.reject(&order, ErrNoPegReference)
+		}
+		order.Price = price
+	}
+
+	var report ExecutionReport
+	if order.isStop() {
+		if order.isTrailing() {
+			ob.trailStop(&order, ob.lastPrice)
+		}
+		if !order.StopPrice.IsPositive() {
+			return ob.reject(&order, ErrInvalidStopPrice)
+		}
+		ob.stops.add(&order)
+		ob.trackExpiry(&order)
+		ob.emitOrder(EventOrderAccepted, &order, "")
+		report = ExecutionReport{
+			OrderID:           order.ID,
+			Status:            StatusNew,
+			Price:             order.Price,
+			RemainingQuantity: order.Quantity,
+		}
+	} else {
+		limit, err := ob.admit(&order)
+		if err != nil {
+			return ob.reject(&order, err)
+		}
+		ob.emitOrder(EventOrderAccepted, &order, "")
+		report = ob.execute(&order, limit)
+	}
+
+	report.Triggered = ob.activateStops()
+	return report, nil
+}
+
+func (ob *OrderBook) reject(order *Order, err error) (ExecutionReport, error) {
+	ob.emitOrder(EventOrderRejected, order, err.Error())
+	return ExecutionReport{OrderID: order.ID, Status: StatusRejected, RemainingQuantity: order.Quantity}, err
+}
+
+// admit runs the checks that depend on the state of the book and returns the
+// limit to match with. Post-only orders may be re-priced here.
+func (ob *OrderBook) admit(order *Order) (Decimal, error) {
+	auction := ob.session == SessionPreOpen
+	if auction && !order.rests() {
+		return Decimal{}, ErrAuctionOrderType
+	}
+	if order.PostOnly != PostOnlyOff && !auction {
+		if err := ob.applyPostOnly(order); err != nil {
+			return Decimal{}, err
+		}
+	}
+
+	if err := ob.checkBand(order); err != nil {
+		return Decimal{}, err
+	}
+	if order.rests() && !ob.levelFits(order.Side, order.Price, order.Quantity) {
+		return Decimal{}, ErrLevelFull
+	}
+
+	limit := order.Price
+	if order.Type == Market {
+		var err error
+		if limit, err = ob.marketLimit(order); err != nil {
+			return Decimal{}, err
+		}
+	}
+
+	if order.TimeInForce == FOK && !ob.fillable(order, limit) {
+		return Decimal{}, ErrFillOrKill
+	}
+	return limit, nil
+}
+
+// execute runs an admitted, non-stop order against the book and rests or
+// cancels what is left. During an auction the order rests without matching.
+func (ob *OrderBook) execute(order *Order, limit Decimal) ExecutionReport {
+	var result matchResult
+	if ob.session != SessionPreOpen {
+		result = ob.match(order, limit)
+	}
+
+	report := ExecutionReport{
+		OrderID:            order.ID,
+		Price:              order.Price,
+		Trades:             result.trades,
+		RemainingQuantity:  order.Quantity,
+		SelfTradePrevented: result.prevented,
+	}
+	for _, trade := range result.trades {
+		report.FilledQuantity = report.FilledQuantity.Add(trade.Quantity)
+	}
+	// Quantity taken off by self-trade prevention was canceled, not filled.
+	decremented := false
+	for _, event := range result.prevented {
+		decremented = decremented || event.OrderID == order.ID
+	}
+
+	switch {
+	case result.canceled, order.Quantity.IsZero() && decremented:
+		report.Status = StatusCanceled
+	case order.Quantity.IsZero():
+		report.Status = StatusFilled
+	case !order.rests():
+		report.Status = StatusCanceled
+		ob.emitOrder(EventOrderCanceled, order, ReasonRemainder)
+	case ob.halted() && ob.wouldCross(order):
+		// The sweep stopped on a halt; resting would leave the book crossed.
+		report.Status = StatusCanceled
+		ob.emitOrder(EventOrderCanceled, order, ReasonCircuitBreaker)
+	case len(result.trades) > 0:
+		report.Status = StatusPartiallyFilled
+		ob.rest(order)
+	default:
+		report.Status = StatusNew
+		ob.rest(order)
+	}
+	return report
+}
+
+// Prices and quantities of orders are bounded so that the book's arithmetic
+// on them cannot overflow: any two of them align at orderScale, and the
+// quantity resting at one price never exceeds maxLevelQuantity.
+const orderScale = 9
+
+var (
+	maxOrderValue    = NewDecimal(1_000_000_000, 0)
+	maxLevelQuantity = NewDecimal(math.MaxInt64, orderScale)
+)
+
+// inRange reports whether a price or quantity is within the bounds above.
+func inRange(d Decimal) bool {
+	if d.Sign() < 0 {
+		d = d.Neg()
+	}
+	return d.normalize().scale <= orderScale && !d.GreaterThan(maxOrderValue)
+}
+
+// levelFits reports whether quantity can join the orders resting at price.
+func (ob *OrderBook) levelFits(side OrderSide, price, quantity Decimal) bool {
+	total := quantity
+	if queue, found := ob.tree(side).Get(price); found {
+		for e := queue.Front(); e != nil; e = e.Next() {
+			var ok bool
+			if total, ok = add(total, e.Value.(*Order).Quantity); !ok {
+				return false
+			}
+		}
+	}
+	return !total.GreaterThan(maxLevelQuantity)
+}
+
+func validate(order *Order) error {
+	if !order.Quantity.IsPositive() {
+		return ErrInvalidQuantity
+	}
+	for _, d := range []Decimal{order.Quantity, order.Price, order.StopPrice, order.ProtectionPrice, order.DisplayQuantity, order.TrailingAmount, order.PegOffset, order.PegCap} {
+		if !inRange(d) {
+			return ErrOutOfRange
+		}
+	}
+
+	if order.pegged() && (order.Type != Limit || order.Peg > PegMid || order.PegCap.Sign() < 0 || order.PostOnly == PostOnlyReprice) {
+		return ErrInvalidPeg
+	}
+
+	switch order.Type {
+	case Limit, StopLimit:
+		if !order.Price.IsPositive() && !order.pegged() {
+			return ErrInvalidPrice
+		}
+	case Market, Stop:
+		if order.ProtectionPrice.Sign() < 0 {
+			return ErrInvalidPrice
+		}
+		if order.MaxSlippage.Sign() < 0 {
+			return ErrInvalidSlippage
+		}
+	default:
+		return ErrInvalidOrderType
+	}
+
+	if order.isStop() && (order.StopPrice.Sign() < 0 || order.StopPrice.IsZero() && !order.isTrailing()) {
+		return ErrInvalidStopPrice
+	}
+	if !order.TrailingAmount.IsZero() || !order.TrailingPercent.IsZero() {
+		if !order.isStop() || order.TrailingAmount.Sign() < 0 || order.TrailingPercent.Sign() < 0 {
+			return ErrInvalidTrailing
+		}
+		if order.TrailingAmount.IsPositive() == order.TrailingPercent.IsPositive() || order.TrailingPercent.Cmp(one) >= 0 {
+			return ErrInvalidTrailing
+		}
+	}
+
+	if order.PostOnly != PostOnlyOff && !order.rests() {
+		return ErrInvalidPostOnly
+	}
+	if !order.DisplayQuantity.IsZero() {
+		if !order.DisplayQuantity.IsPositive() || order.DisplayQuantity.GreaterThan(order.Quantity) {
+			return ErrInvalidDisplayQuantity
+		}
+		if order.Type != Limit && order.Type != StopLimit {
+			return ErrInvalidDisplayQuantity
+		}
+	}
+
+	switch order.TimeInForce {
+	case GTC, IOC, FOK:
+	case GTD:
+		if !order.ExpireTime.After(order.Timestamp) {
+			return ErrInvalidExpireTime
+		}
+	default:
+		return ErrInvalidTimeInForce
+	}
+	return nil
+}
+
+// fillable reports whether the opposite side holds at least the order's
+// quantity at prices within limit, without modifying anything. Self-trade
+// prevention is applied as match would: resting orders of the same account do
+// not count, and reaching one ends the sweep under STPCancelNewest and
+// STPCancelBoth or takes the decrement off the order under
+// STPDecrementAndCancel. Of an order list only the first member counts, as
+// trading with it cancels the others.
+func (ob *OrderBook) fillable(order *Order, limit Decimal) bool {
+	available, needed := Decimal{}, order.Quantity
+
+	// A trade that trips the circuit breaker ends the sweep: right after it
+	// under FIFO, at the end of its level under other policies.
+	_, fifo := ob.policy.(FIFO)
+	breaker := ob.probeBreaker()
+	halts := false
+	lists := make(map[string]bool)
+
+	iter := ob.tree(order.Side.Opposite()).Iterator()
+	for iter.Next() {
+		price := iter.Key()
+		if halts || !limit.IsZero() && !crosses(order.Side, limit, price) {
+			break
+		}
+		for e := iter.Value().Front(); e != nil; e = e.Next() {
+			maker := e.Value.(*Order)
+			if maker.ListID != "" {
+				if lists[maker.ListID] {
+					continue
+				}
+				lists[maker.ListID] = true
+			}
+			if ob.isSelfTrade(maker, order) {
+				switch ob.stp {
+				case STPCancelNewest, STPCancelBoth:
+					return false
+				case STPDecrementAndCancel:
+					needed = needed.Sub(minDecimal(maker.Quantity, needed.Sub(available)))
+					if available.Cmp(needed) >= 0 {
+						return true
+					}
+				}
+				continue
+			}
+			if halts && fifo {
+				return false
+			}
+			available = available.Add(maker.Quantity)
+			if available.Cmp(needed) >= 0 {
+				return true
+			}
+			halts = breaker.trade(price) || halts
+		}
+	}
+	return false
+}
+
+type matchResult struct {
+	trades    []Trade
+	prevented []SelfTradeEvent
+	canceled  bool // Taker remainder canceled by self-trade prevention
+}
+
+// match sweeps the side opposite to the taker from the best price inwards,
+// filling against resting orders in queue order. A zero limit leaves the sweep
+// unbounded, otherwise it stops at the first level the limit does not cross.
+func (ob *OrderBook) match(taker *Order, limit Decimal) matchResult {
+	var result matchResult
+
+	makerSide := taker.Side.Opposite()
+	tree := ob.tree(makerSide)
+
+	for taker.Quantity.IsPositive() && !tree.Empty() && !ob.halted() {
+		iter := tree.Iterator()
+		if !iter.Next() {
+			break
+		}
+
+		price := iter.Key()
+		if !limit.IsZero() && !crosses(taker.Side, limit, price) {
+			break
+		}
+
+		queue := iter.Value()
+		e := queue.Front()
+		maker := e.Value.(*Order)
+
+		if ob.isSelfTrade(maker, taker) {
+			events, done := ob.preventSelfTrade(maker, taker)
+			result.prevented = append(result.prevented, events...)
+			if done {
+				result.canceled = true
+				break
+			}
+			continue
+		}
+
+		if _, fifo := ob.policy.(FIFO); fifo {
+			ob.fill(taker, makerSide, price, queue, e, minDecimal(maker.displayed(), taker.Quantity), &result)
+			continue
+		}
+		ob.allocate(taker, makerSide, price, queue, &result)
+	}
+	return result
+}
+
+// allocate lets the matching policy split the taker's quantity across the
+// level, up to the first order that would be a self-trade.
+func (ob *OrderBook) allocate(taker *Order, makerSide OrderSide, price Decimal, queue *list.List, result *matchResult) {
+	var elements []*list.Element
+	var sizes []Decimal
+	for e := queue.Front(); e != nil; e = e.Next() {
+		maker := e.Value.(*Order)
+		if ob.isSelfTrade(maker, taker) {
+			break
+		}
+		elements = append(elements, e)
+		sizes = append(sizes, maker.displayed())
+	}
+
+	unit := ob.lotSize
+	if !unit.IsPositive() {
+		unit = smallestUnit(taker.Quantity, sizes)
+	}
+
+	filled := false
+	for i, quantity := range ob.policy.Allocate(sizes, taker.Quantity, unit) {
+		// A fill may have canceled the rest of the maker's order list.
+		if ob.orders[elements[i].Value.(*Order).ID] != elements[i] {
+			continue
+		}
+		if quantity.IsPositive() {
+			ob.fill(taker, makerSide, price, queue, elements[i], quantity, result)
+			filled = true
+		}
+	}
+
+	// A policy that allocates nothing would stall the sweep; fall back to
+	// price-time for the front order.
+	if !filled {
+		ob.fill(taker, makerSide, price, queue, elements[0], minDecimal(sizes[0], taker.Quantity), result)
+	}
+}
+
+func (ob *OrderBook) fill(taker *Order, makerSide OrderSide, price Decimal, queue *list.List, e *list.Element, quantity Decimal, result *matchResult) {
+	trade := ob.newTrade(e.Value.(*Order), taker, price, quantity)
+	result.trades = append(result.trades, trade)
+
+	taker.Quantity = taker.Quantity.Sub(quantity)
+	ob.emitFill(taker, &trade)
+	ob.consume(makerSide, price, queue, e, &trade)
+}
+
+// wouldCross reports whether a limit order would trade against the best
+// opposite price.
+func (ob *OrderBook) wouldCross(order *Order) bool {
+	best, found := ob.bestPrice(order.Side.Opposite())
+	return found && crosses(order.Side, order.Price, best)
+}
+
+// crosses reports whether an order on side with the given limit can trade
+// against a resting price.
+func crosses(side OrderSide, limit, price Decimal) bool {
+	if side == Bid {
+		return limit.Cmp(price) >= 0
+	}
+	return limit.Cmp(price) <= 0
+}
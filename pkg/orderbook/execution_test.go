@@ -0,0 +1,353 @@
+package orderbook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPlaceOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		resting       []Order
+		order         Order
+		wantErr       error
+		wantStatus    OrderStatus
+		wantFilled    string
+		wantRemaining string
+		wantTrades    int
+		wantBestBid   string
+		wantBestAsk   string
+	}{
+		{
+			name:          "rests_when_not_crossing",
+			resting:       []Order{{ID: "a1", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("1")}},
+			order:         Order{ID: "b1", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")},
+			wantStatus:    StatusNew,
+			wantFilled:    "0",
+			wantRemaining: "1",
+			wantBestBid:   "100",
+			wantBestAsk:   "101",
+		},
+		{
+			name:          "fills_completely",
+			resting:       []Order{{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("2")}},
+			order:         Order{ID: "b1", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("2")},
+			wantStatus:    StatusFilled,
+			wantFilled:    "2",
+			wantRemaining: "0",
+			wantTrades:    1,
+			wantBestBid:   "0",
+			wantBestAsk:   "0",
+		},
+		{
+			name: "rests_remainder_after_sweep",
+			resting: []Order{
+				{ID: "b1", Side: Bid, Price: MustDecimal("101"), Quantity: MustDecimal("1")},
+				{ID: "b2", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")},
+				{ID: "b3", Side: Bid, Price: MustDecimal("99"), Quantity: MustDecimal("1")},
+			},
+			order:         Order{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("3")},
+			wantStatus:    StatusPartiallyFilled,
+			wantFilled:    "2",
+			wantRemaining: "1",
+			wantTrades:    2,
+			wantBestBid:   "99",
+			wantBestAsk:   "100",
+		},
+		{
+			name:          "rejects_zero_quantity",
+			order:         Order{ID: "b1", Side: Bid, Price: MustDecimal("100")},
+			wantErr:       ErrInvalidQuantity,
+			wantStatus:    StatusRejected,
+			wantFilled:    "0",
+			wantRemaining: "0",
+			wantBestBid:   "0",
+			wantBestAsk:   "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook()
+			for _, order := range tt.resting {
+				ob.InsertOrder(order)
+			}
+
+			report, err := ob.PlaceOrder(tt.order)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PlaceOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			if report.Status != tt.wantStatus {
+				t.Errorf("Status = %v, want %v", report.Status, tt.wantStatus)
+			}
+			if report.FilledQuantity.String() != tt.wantFilled || report.RemainingQuantity.String() != tt.wantRemaining {
+				t.Errorf("filled/remaining = %v/%v, want %v/%v",
+					report.FilledQuantity, report.RemainingQuantity, tt.wantFilled, tt.wantRemaining)
+			}
+			if len(report.Trades) != tt.wantTrades {
+				t.Errorf("got %d trades, want %d", len(report.Trades), tt.wantTrades)
+			}
+
+			bid, _, _ := ob.GetBestBid()
+			ask, _, _ := ob.GetBestAsk()
+			if bid.String() != tt.wantBestBid || ask.String() != tt.wantBestAsk {
+				t.Errorf("best bid/ask = %v/%v, want %v/%v", bid, ask, tt.wantBestBid, tt.wantBestAsk)
+			}
+		})
+	}
+}
+
+func TestPlaceMarketOrder(t *testing.T) {
+	asks := []Order{
+		{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1")},
+		{ID: "a2", Side: Ask, Price: MustDecimal("102"), Quantity: MustDecimal("1")},
+		{ID: "a3", Side: Ask, Price: MustDecimal("150"), Quantity: MustDecimal("1")},
+	}
+
+	tests := []struct {
+		name       string
+		order      Order
+		wantStatus OrderStatus
+		wantFilled string
+		wantAsks   int
+	}{
+		{
+			name:       "sweeps_until_filled",
+			order:      Order{ID: "m1", Side: Bid, Type: Market, Quantity: MustDecimal("2")},
+			wantStatus: StatusFilled,
+			wantFilled: "2",
+			wantAsks:   1,
+		},
+		{
+			name:       "remainder_canceled_when_liquidity_runs_out",
+			order:      Order{ID: "m1", Side: Bid, Type: Market, Quantity: MustDecimal("5")},
+			wantStatus: StatusCanceled,
+			wantFilled: "3",
+			wantAsks:   0,
+		},
+		{
+			name:       "protection_price",
+			order:      Order{ID: "m1", Side: Bid, Type: Market, Quantity: MustDecimal("3"), ProtectionPrice: MustDecimal("110")},
+			wantStatus: StatusCanceled,
+			wantFilled: "2",
+			wantAsks:   1,
+		},
+		{
+			name:       "max_slippage",
+			order:      Order{ID: "m1", Side: Bid, Type: Market, Quantity: MustDecimal("3"), MaxSlippage: MustDecimal("0.01")},
+			wantStatus: StatusCanceled,
+			wantFilled: "1",
+			wantAsks:   2,
+		},
+		{
+			name: "tighter_bound_wins",
+			order: Order{ID: "m1", Side: Bid, Type: Market, Quantity: MustDecimal("3"),
+				ProtectionPrice: MustDecimal("100.5"), MaxSlippage: MustDecimal("0.05")},
+			wantStatus: StatusCanceled,
+			wantFilled: "1",
+			wantAsks:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook()
+			for _, order := range asks {
+				ob.InsertOrder(order)
+			}
+
+			report, err := ob.PlaceOrder(tt.order)
+			if err != nil {
+				t.Fatalf("PlaceOrder() error = %v", err)
+			}
+			if report.Status != tt.wantStatus || report.FilledQuantity.String() != tt.wantFilled {
+				t.Errorf("status/filled = %v/%s, want %v/%s", report.Status, report.FilledQuantity, tt.wantStatus, tt.wantFilled)
+			}
+			if ob.Asks.Size() != tt.wantAsks {
+				t.Errorf("got %d ask levels, want %d", ob.Asks.Size(), tt.wantAsks)
+			}
+			if _, found := ob.GetOrder(tt.order.ID); found {
+				t.Errorf("market order must never rest")
+			}
+		})
+	}
+}
+
+func TestPlaceOrderTimeInForce(t *testing.T) {
+	asks := []Order{
+		{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1")},
+		{ID: "a2", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("1")},
+	}
+
+	tests := []struct {
+		name       string
+		order      Order
+		wantErr    error
+		wantStatus OrderStatus
+		wantFilled string
+		wantRests  bool
+		wantAsks   int
+	}{
+		{
+			name:       "ioc_cancels_remainder",
+			order:      Order{ID: "b1", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("2"), TimeInForce: IOC},
+			wantStatus: StatusCanceled,
+			wantFilled: "1",
+			wantAsks:   1,
+		},
+		{
+			name:       "fok_fills_in_full",
+			order:      Order{ID: "b1", Side: Bid, Price: MustDecimal("101"), Quantity: MustDecimal("2"), TimeInForce: FOK},
+			wantStatus: StatusFilled,
+			wantFilled: "2",
+			wantAsks:   0,
+		},
+		{
+			name:       "fok_rejected_without_side_effects",
+			order:      Order{ID: "b1", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("2"), TimeInForce: FOK},
+			wantErr:    ErrFillOrKill,
+			wantStatus: StatusRejected,
+			wantFilled: "0",
+			wantAsks:   2,
+		},
+		{
+			name: "gtd_rests",
+			order: Order{ID: "b1", Side: Bid, Price: MustDecimal("99"), Quantity: MustDecimal("1"), TimeInForce: GTD,
+				ExpireTime: time.Now().Add(time.Hour)},
+			wantStatus: StatusNew,
+			wantFilled: "0",
+			wantRests:  true,
+			wantAsks:   2,
+		},
+		{
+			name:       "gtd_requires_expire_time",
+			order:      Order{ID: "b1", Side: Bid, Price: MustDecimal("99"), Quantity: MustDecimal("1"), TimeInForce: GTD},
+			wantErr:    ErrInvalidExpireTime,
+			wantStatus: StatusRejected,
+			wantFilled: "0",
+			wantAsks:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook()
+			for _, order := range asks {
+				ob.InsertOrder(order)
+			}
+
+			report, err := ob.PlaceOrder(tt.order)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PlaceOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			if report.Status != tt.wantStatus || report.FilledQuantity.String() != tt.wantFilled {
+				t.Errorf("status/filled = %v/%s, want %v/%s", report.Status, report.FilledQuantity, tt.wantStatus, tt.wantFilled)
+			}
+			if _, found := ob.GetOrder(tt.order.ID); found != tt.wantRests {
+				t.Errorf("order rests = %v, want %v", found, tt.wantRests)
+			}
+			if ob.Asks.Size() != tt.wantAsks {
+				t.Errorf("got %d ask levels, want %d", ob.Asks.Size(), tt.wantAsks)
+			}
+		})
+	}
+}
+
+func TestPlacePostOnlyOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		order     Order
+		wantErr   error
+		wantPrice string
+	}{
+		{
+			name:      "rests_when_not_crossing",
+			order:     Order{ID: "b1", Side: Bid, Price: MustDecimal("100.00"), Quantity: MustDecimal("1"), PostOnly: PostOnlyReject},
+			wantPrice: "100.00",
+		},
+		{
+			name:    "rejects_when_crossing",
+			order:   Order{ID: "b1", Side: Bid, Price: MustDecimal("101.00"), Quantity: MustDecimal("1"), PostOnly: PostOnlyReject},
+			wantErr: ErrPostOnlyWouldCross,
+		},
+		{
+			name:      "reprices_bid_below_best_ask",
+			order:     Order{ID: "b1", Side: Bid, Price: MustDecimal("105.00"), Quantity: MustDecimal("1"), PostOnly: PostOnlyReprice},
+			wantPrice: "100.99",
+		},
+		{
+			name:      "reprices_ask_above_best_bid",
+			order:     Order{ID: "a1", Side: Ask, Price: MustDecimal("90.00"), Quantity: MustDecimal("1"), PostOnly: PostOnlyReprice},
+			wantPrice: "99.01",
+		},
+		{
+			name:    "rejects_ioc",
+			order:   Order{ID: "b1", Side: Bid, Price: MustDecimal("100.00"), Quantity: MustDecimal("1"), PostOnly: PostOnlyReject, TimeInForce: IOC},
+			wantErr: ErrInvalidPostOnly,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook(WithTickSize(MustDecimal("0.01")))
+			ob.InsertOrder(Order{ID: "rest-bid", Side: Bid, Price: MustDecimal("99.00"), Quantity: MustDecimal("1")})
+			ob.InsertOrder(Order{ID: "rest-ask", Side: Ask, Price: MustDecimal("101.00"), Quantity: MustDecimal("1")})
+
+			report, err := ob.PlaceOrder(tt.order)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PlaceOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(report.Trades) != 0 || report.Status != StatusNew {
+				t.Errorf("post-only order traded: %+v", report)
+			}
+			if report.Price.String() != tt.wantPrice {
+				t.Errorf("Price = %s, want %s", report.Price, tt.wantPrice)
+			}
+		})
+	}
+}
+
+func TestPlaceOrderRange(t *testing.T) {
+	ob := NewOrderBook()
+	ob.Subscribe(ListenerFunc(func(Event) {}))
+
+	tests := []struct {
+		name  string
+		order Order
+		want  error
+	}{
+		{"huge quantity", Order{Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("9000000000000000000")}, ErrOutOfRange},
+		{"huge price", Order{Side: Ask, Price: MustDecimal("1000000000.5"), Quantity: MustDecimal("1")}, ErrOutOfRange},
+		{"too precise", Order{Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("0.0000000001")}, ErrOutOfRange},
+		{"trailing zeros", Order{Side: Ask, Price: MustDecimal("100.0000000000"), Quantity: MustDecimal("1")}, nil},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.order.ID = string(rune('a' + i))
+			if _, err := ob.PlaceOrder(tt.order); err != tt.want {
+				t.Errorf("PlaceOrder() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// 9 orders of 1000000000 fill the level as far as a Decimal at 9 decimal
+	// places can count.
+	for i := 0; i < 9; i++ {
+		if _, err := ob.PlaceOrder(Order{ID: string(rune('p' + i)), Side: Bid, Price: MustDecimal("50"), Quantity: MustDecimal("1000000000")}); err != nil {
+			t.Fatalf("PlaceOrder(%d) error = %v", i, err)
+		}
+	}
+	if _, err := ob.PlaceOrder(Order{ID: "full", Side: Bid, Price: MustDecimal("50"), Quantity: MustDecimal("1000000000")}); err != ErrLevelFull {
+		t.Errorf("PlaceOrder(full) error = %v, want %v", err, ErrLevelFull)
+	}
+	if _, err := ob.AmendOrder("p", Decimal{}, MustDecimal("0.0000000001")); err != ErrOutOfRange {
+		t.Errorf("AmendOrder() error = %v, want %v", err, ErrOutOfRange)
+	}
+	if _, qty, _ := ob.GetBestBid(); qty.String() != "9000000000" {
+		t.Errorf("best bid quantity = %s, want 9000000000", qty)
+	}
+}
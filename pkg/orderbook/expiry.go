@@ -0,0 +1,100 @@
+package orderbook
+
+import (
+	"container/heap"
+	"context"
+	"time"
+)
+
+// expiryQueue is a min-heap of resting GTD orders ordered by expire time.
+// Entries are not removed when an order fills or is canceled; ExpireOrders
+// skips them when they surface.
+type expiryQueue []*Order
+
+func (q expiryQueue) Len() int { return len(q) }
+
+func (q expiryQueue) Less(i, j int) bool {
+	if q[i].ExpireTime.Equal(q[j].ExpireTime) {
+		return q[i].Timestamp.Before(q[j].Timestamp)
+	}
+	return q[i].ExpireTime.Before(q[j].ExpireTime)
+}
+
+func (q expiryQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *expiryQueue) Push(x any) { *q = append(*q, x.(*Order)) }
+
+func (q *expiryQueue) Pop() any {
+	old := *q
+	n := len(old)
+	order := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return order
+}
+
+// ExpireOrders removes every resting or dormant GTD order whose expire time is not after
+// now and returns them in expiry order.
+func (ob *OrderBook) ExpireOrders(now time.Time) []Order {
+	ob.Lock()
+	defer ob.Unlock()
+	ob.begin(now)
+
+	return ob.expireOrders()
+}
+
+func (ob *OrderBook) expireOrders() []Order {
+	defer ob.repeg()
+
+	var expired []Order
+	for ob.expiries.Len() > 0 && !ob.expiries[0].ExpireTime.After(ob.now) {
+		order := heap.Pop(&ob.expiries).(*Order)
+
+		if e, found := ob.orders[order.ID]; found && e.Value.(*Order) == order {
+			ob.remove(e)
+		} else if e, found := ob.stops.orders[order.ID]; found && e.Value.(*Order) == order {
+			ob.stops.remove(e)
+		} else {
+			continue
+		}
+
+		ob.emitOrder(EventOrderExpired, order, "")
+		expired = append(expired, *order)
+	}
+	return expired
+}
+
+// ExpiryScheduler periodically expires GTD orders of a book and hands each
+// expired order to onExpire.
+type ExpiryScheduler struct {
+	book     *OrderBook
+	interval time.Duration
+	onExpire func(Order)
+}
+
+func NewExpiryScheduler(book *OrderBook, interval time.Duration, onExpire func(Order)) *ExpiryScheduler {
+	return &ExpiryScheduler{
+		book:     book,
+		interval: interval,
+		onExpire: onExpire,
+	}
+}
+
+// Run blocks until ctx is done.
+func (s *ExpiryScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, order := range s.book.ExpireOrders(now) {
+				if s.onExpire != nil {
+					s.onExpire(order)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpireOrders(t *testing.T) {
+	now := time.Now()
+	ob := NewOrderBook()
+
+	place := func(id string, expireIn time.Duration) {
+		_, err := ob.PlaceOrder(Order{
+			ID: id, Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1"),
+			Timestamp: now, TimeInForce: GTD, ExpireTime: now.Add(expireIn),
+		})
+		if err != nil {
+			t.Fatalf("PlaceOrder(%s) error = %v", id, err)
+		}
+	}
+	place("late", 3*time.Minute)
+	place("early", time.Minute)
+	place("canceled", 2*time.Minute)
+	ob.InsertOrder(Order{ID: "gtc", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1"), Timestamp: now})
+
+	if _, err := ob.CancelOrder("canceled"); err != nil {
+		t.Fatalf("CancelOrder() error = %v", err)
+	}
+
+	if expired := ob.ExpireOrders(now); len(expired) != 0 {
+		t.Errorf("ExpireOrders(now) expired %d orders, want 0", len(expired))
+	}
+
+	expired := ob.ExpireOrders(now.Add(5 * time.Minute))
+	if len(expired) != 2 || expired[0].ID != "early" || expired[1].ID != "late" {
+		t.Fatalf("ExpireOrders() = %+v, want early then late", expired)
+	}
+
+	if _, found := ob.GetOrder("gtc"); !found {
+		t.Errorf("GTC order should not expire")
+	}
+	if _, found := ob.GetOrder("late"); found {
+		t.Errorf("expired order is still resting")
+	}
+}
@@ -0,0 +1,61 @@
+package orderbook
+
+import "testing"
+
+func TestIcebergOrder(t *testing.T) {
+	ob := NewOrderBook()
+
+	if _, err := ob.PlaceOrder(Order{ID: "ice", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("5"), DisplayQuantity: MustDecimal("2")}); err != nil {
+		t.Fatalf("PlaceOrder(ice) error = %v", err)
+	}
+	if _, err := ob.PlaceOrder(Order{ID: "a2", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1")}); err != nil {
+		t.Fatalf("PlaceOrder(a2) error = %v", err)
+	}
+
+	if _, qty, _ := ob.GetBestAsk(); qty.String() != "3" {
+		t.Errorf("best ask quantity = %s, want the displayed 2 plus 1", qty)
+	}
+
+	// Taking 3 uses up the visible slice, which replenishes behind a2, so the
+	// last unit comes from a2.
+	report, err := ob.PlaceOrder(Order{ID: "b1", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("3")})
+	if err != nil {
+		t.Fatalf("PlaceOrder(b1) error = %v", err)
+	}
+
+	want := []struct {
+		maker string
+		qty   string
+	}{
+		{maker: "ice", qty: "2"},
+		{maker: "a2", qty: "1"},
+	}
+	if len(report.Trades) != len(want) {
+		t.Fatalf("got %d trades, want %d", len(report.Trades), len(want))
+	}
+	for i, w := range want {
+		if report.Trades[i].MakerOrderID != w.maker || report.Trades[i].Quantity.String() != w.qty {
+			t.Errorf("trade %d = %+v, want %s x %s", i, report.Trades[i], w.maker, w.qty)
+		}
+	}
+
+	order, found := ob.GetOrder("ice")
+	if !found || order.Quantity.String() != "3" {
+		t.Fatalf("GetOrder(ice) = %+v, %v, want 3 remaining", order, found)
+	}
+	if _, qty, _ := ob.GetBestAsk(); qty.String() != "2" {
+		t.Errorf("best ask quantity after replenish = %s, want 2", qty)
+	}
+
+	// The reserve keeps replenishing until the order is gone.
+	report, err = ob.PlaceOrder(Order{ID: "b2", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("3")})
+	if err != nil {
+		t.Fatalf("PlaceOrder(b2) error = %v", err)
+	}
+	if report.Status != StatusFilled || len(report.Trades) != 2 {
+		t.Errorf("b2 report = %+v, want filled in two slices", report)
+	}
+	if _, found := ob.GetOrder("ice"); found {
+		t.Errorf("iceberg should be fully filled")
+	}
+}
@@ -0,0 +1,40 @@
+package orderbook
+
+var one = NewDecimal(1, 0)
+
+// marketLimit derives the worst acceptable price for a market order from its
+// protection price and slippage bound. A zero result means the order may sweep
+// the whole opposite side.
+func (ob *OrderBook) marketLimit(order *Order) (Decimal, error) {
+	limit := order.ProtectionPrice
+
+	if order.MaxSlippage.IsPositive() {
+		best, found := ob.bestPrice(order.Side.Opposite())
+		if !found {
+			return limit, nil
+		}
+
+		factor := one.Add(order.MaxSlippage)
+		if order.Side == Ask {
+			factor = one.Sub(order.MaxSlippage)
+		}
+
+		bound, err := best.Mul(factor)
+		if err != nil {
+			return Decimal{}, err
+		}
+
+		if limit.IsZero() || crosses(order.Side, limit, bound) {
+			limit = bound
+		}
+	}
+	return limit, nil
+}
+
+func (ob *OrderBook) bestPrice(side OrderSide) (Decimal, bool) {
+	iter := ob.tree(side).Iterator()
+	if !iter.Next() {
+		return Decimal{}, false
+	}
+	return iter.Key(), true
+}
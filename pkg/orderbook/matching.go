@@ -0,0 +1,161 @@
+package orderbook
+
+import "math/bits"
+
+// MatchingPolicy splits an incoming quantity across the orders resting at one
+// price level. sizes holds the displayed quantity of each order in queue
+// order. Allocate returns one amount per order, each at most its size and
+// rounded to unit where possible, adding up to the smaller of quantity and the
+// level total.
+type MatchingPolicy interface {
+	Allocate(sizes []Decimal, quantity, unit Decimal) []Decimal
+}
+
+// FIFO fills orders strictly in time priority.
+type FIFO struct{}
+
+func (FIFO) Allocate(sizes []Decimal, quantity, unit Decimal) []Decimal {
+	a := newAllocation(sizes, quantity, unit)
+	a.fifo(a.remaining)
+	return a.result()
+}
+
+// ProRata shares the incoming quantity in proportion to resting size.
+// TopOrder fills the first order of the level before sharing. FIFOShare, a
+// fraction such as 0.4, allocates that part of the quantity in time priority
+// first, which makes the policy a FIFO and pro-rata hybrid. Shares smaller
+// than MinAllocation are not given out pro-rata; whatever is left after
+// rounding goes to orders in time priority.
+type ProRata struct {
+	TopOrder      bool
+	FIFOShare     Decimal
+	MinAllocation Decimal
+}
+
+func (p ProRata) Allocate(sizes []Decimal, quantity, unit Decimal) []Decimal {
+	a := newAllocation(sizes, quantity, unit)
+
+	if p.TopOrder && len(sizes) > 0 {
+		a.take(0, a.remaining)
+	}
+
+	if p.FIFOShare.IsPositive() {
+		share := p.FIFOShare
+		if share.GreaterThan(one) {
+			share = one
+		}
+		a.fifo(a.round(mulDiv(a.remaining, share.mantissa, pow10[share.scale])))
+	}
+
+	if a.remaining > 0 {
+		var capacity int64
+		for i := range a.sizes {
+			capacity += a.sizes[i] - a.alloc[i]
+		}
+
+		minimum := a.at(p.MinAllocation)
+		base := a.remaining
+		for i := range a.sizes {
+			share := a.round(mulDiv(base, a.sizes[i]-a.alloc[i], capacity))
+			if share > 0 && share >= minimum {
+				a.take(i, share)
+			}
+		}
+	}
+
+	a.fifo(a.remaining)
+	return a.result()
+}
+
+// allocation does the arithmetic of a policy on int64 mantissas at a scale
+// common to every input.
+type allocation struct {
+	scale     uint8
+	unit      int64
+	sizes     []int64
+	alloc     []int64
+	remaining int64
+}
+
+func newAllocation(sizes []Decimal, quantity, unit Decimal) *allocation {
+	scale := max(quantity.scale, unit.scale)
+	for _, size := range sizes {
+		scale = max(scale, size.scale)
+	}
+
+	a := &allocation{
+		scale: scale,
+		sizes: make([]int64, len(sizes)),
+		alloc: make([]int64, len(sizes)),
+	}
+
+	var total int64
+	for i, size := range sizes {
+		a.sizes[i] = a.at(size)
+		total += a.sizes[i]
+	}
+	a.remaining = min(a.at(quantity), total)
+
+	a.unit = a.at(unit)
+	if a.unit <= 0 {
+		a.unit = 1
+	}
+	return a
+}
+
+func (a *allocation) at(d Decimal) int64 {
+	if d.scale >= a.scale {
+		return d.mantissa
+	}
+	return d.mantissa * pow10[a.scale-d.scale]
+}
+
+func (a *allocation) round(v int64) int64 {
+	return v - v%a.unit
+}
+
+func (a *allocation) take(i int, amount int64) {
+	amount = min(amount, a.sizes[i]-a.alloc[i], a.remaining)
+	a.alloc[i] += amount
+	a.remaining -= amount
+}
+
+func (a *allocation) fifo(amount int64) {
+	for i := range a.sizes {
+		if amount <= 0 {
+			return
+		}
+		before := a.remaining
+		a.take(i, amount)
+		amount -= before - a.remaining
+	}
+}
+
+func (a *allocation) result() []Decimal {
+	result := make([]Decimal, len(a.alloc))
+	for i, v := range a.alloc {
+		result[i] = Decimal{mantissa: v, scale: a.scale}
+	}
+	return result
+}
+
+// mulDiv returns a*b/c rounded down, for non-negative a and b not exceeding a
+// positive c, without overflowing on the intermediate product.
+func mulDiv(a, b, c int64) int64 {
+	if c <= 0 || b >= c {
+		return a
+	}
+	hi, lo := bits.Mul64(uint64(a), uint64(b))
+	quo, _ := bits.Div64(hi, lo, uint64(c))
+	return int64(quo)
+}
+
+// smallestUnit is the finest quantity step among the taker and the level, used
+// for rounding when the book has no lot size.
+func smallestUnit(quantity Decimal, sizes []Decimal) Decimal {
+	scale := quantity.scale
+	for _, size := range sizes {
+		scale = max(scale, size.scale)
+	}
+	return Decimal{mantissa: 1, scale: scale}
+}
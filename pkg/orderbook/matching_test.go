@@ -0,0 +1,134 @@
+package orderbook
+
+import "testing"
+
+func TestMatchingPolicyAllocate(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   MatchingPolicy
+		sizes    []string
+		quantity string
+		unit     string
+		want     []string
+	}{
+		{
+			name:     "fifo",
+			policy:   FIFO{},
+			sizes:    []string{"3", "5", "2"},
+			quantity: "6",
+			unit:     "1",
+			want:     []string{"3", "3", "0"},
+		},
+		{
+			name:     "pro_rata",
+			policy:   ProRata{},
+			sizes:    []string{"10", "30", "60"},
+			quantity: "50",
+			unit:     "1",
+			want:     []string{"5", "15", "30"},
+		},
+		{
+			name:     "pro_rata_rounding_leftover_goes_fifo",
+			policy:   ProRata{},
+			sizes:    []string{"1", "1", "1"},
+			quantity: "2",
+			unit:     "1",
+			want:     []string{"1", "1", "0"},
+		},
+		{
+			name:     "pro_rata_lot_size",
+			policy:   ProRata{},
+			sizes:    []string{"1.0", "3.0"},
+			quantity: "2.0",
+			unit:     "0.5",
+			want:     []string{"0.5", "1.5"},
+		},
+		{
+			name:     "pro_rata_top_order",
+			policy:   ProRata{TopOrder: true},
+			sizes:    []string{"10", "20", "20"},
+			quantity: "30",
+			unit:     "1",
+			want:     []string{"10", "10", "10"},
+		},
+		{
+			name:     "pro_rata_min_allocation",
+			policy:   ProRata{MinAllocation: MustDecimal("5")},
+			sizes:    []string{"2", "98"},
+			quantity: "50",
+			unit:     "1",
+			want:     []string{"1", "49"},
+		},
+		{
+			name:     "hybrid",
+			policy:   ProRata{FIFOShare: MustDecimal("0.5")},
+			sizes:    []string{"10", "10", "20"},
+			quantity: "20",
+			unit:     "1",
+			want:     []string{"10", "4", "6"},
+		},
+		{
+			name:     "quantity_exceeds_level",
+			policy:   ProRata{},
+			sizes:    []string{"1", "2"},
+			quantity: "10",
+			unit:     "1",
+			want:     []string{"1", "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sizes := make([]Decimal, len(tt.sizes))
+			for i, size := range tt.sizes {
+				sizes[i] = MustDecimal(size)
+			}
+
+			got := tt.policy.Allocate(sizes, MustDecimal(tt.quantity), MustDecimal(tt.unit))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Allocate() returned %d amounts, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !got[i].Equal(MustDecimal(want)) {
+					t.Errorf("Allocate() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestProRataBook(t *testing.T) {
+	ob := NewOrderBook(WithMatchingPolicy(ProRata{}), WithLotSize(MustDecimal("1")))
+	ob.InsertOrder(Order{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("10")})
+	ob.InsertOrder(Order{ID: "a2", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("30")})
+	ob.InsertOrder(Order{ID: "a3", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("10")})
+
+	report, err := ob.PlaceOrder(Order{ID: "b1", Side: Bid, Price: MustDecimal("101"), Quantity: MustDecimal("44")})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+
+	want := map[string]string{"a1": "10", "a2": "30", "a3": "4"}
+	got := map[string]string{}
+	for _, trade := range report.Trades {
+		got[trade.MakerOrderID] = trade.Quantity.String()
+	}
+	for id, qty := range want {
+		if got[id] != qty {
+			t.Errorf("%s filled %s, want %s", id, got[id], qty)
+		}
+	}
+
+	ob = NewOrderBook(WithMatchingPolicy(ProRata{}), WithLotSize(MustDecimal("1")))
+	ob.InsertOrder(Order{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("10")})
+	ob.InsertOrder(Order{ID: "a2", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("30")})
+
+	report, err = ob.PlaceOrder(Order{ID: "b2", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("8")})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	if len(report.Trades) != 2 || report.Trades[0].Quantity.String() != "2" || report.Trades[1].Quantity.String() != "6" {
+		t.Errorf("trades = %+v, want 2 from a1 and 6 from a2", report.Trades)
+	}
+}
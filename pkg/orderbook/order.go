@@ -0,0 +1,171 @@
+package orderbook
+
+import "time"
+
+type OrderSide int
+
+const (
+	Bid OrderSide = iota
+	Ask
+)
+
+func (s OrderSide) String() string {
+	if s == Bid {
+		return "Bid"
+	}
+	return "Ask"
+}
+
+func (s OrderSide) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *OrderSide) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "Bid":
+		*s = Bid
+	case "Ask":
+		*s = Ask
+	default:
+		return ErrInvalidSide
+	}
+	return nil
+}
+
+func (s OrderSide) Opposite() OrderSide {
+	if s == Bid {
+		return Ask
+	}
+	return Bid
+}
+
+type OrderType int
+
+const (
+	Limit OrderType = iota
+	Market
+	Stop      // Becomes a market order once triggered
+	StopLimit // Becomes a limit order once triggered
+)
+
+func (t OrderType) String() string {
+	switch t {
+	case Limit:
+		return "Limit"
+	case Market:
+		return "Market"
+	case Stop:
+		return "Stop"
+	case StopLimit:
+		return "StopLimit"
+	}
+	return "Unknown"
+}
+
+type TimeInForce int
+
+const (
+	GTC TimeInForce = iota // Good till canceled
+	IOC                    // Immediate or cancel
+	FOK                    // Fill or kill
+	GTD                    // Good till date, see Order.ExpireTime
+)
+
+func (tif TimeInForce) String() string {
+	switch tif {
+	case GTC:
+		return "GTC"
+	case IOC:
+		return "IOC"
+	case FOK:
+		return "FOK"
+	case GTD:
+		return "GTD"
+	}
+	return "Unknown"
+}
+
+// PostOnly controls what happens to a limit order that would take liquidity on
+// arrival.
+type PostOnly int
+
+const (
+	PostOnlyOff     PostOnly = iota
+	PostOnlyReject           // Reject the order
+	PostOnlyReprice          // Move the price one tick behind the opposite best
+)
+
+type Order struct {
+	ID        string
+	AccountID string // Owner of the order, used for self-trade prevention
+	ListID    string // Order list the order belongs to, set by PlaceOrderList
+	Side      OrderSide
+	Type      OrderType
+	Price     Decimal // Limit price, ignored for market orders
+	Quantity  Decimal
+	Timestamp time.Time
+
+	TimeInForce TimeInForce
+	ExpireTime  time.Time // Required for GTD
+	PostOnly    PostOnly
+
+	// StopPrice triggers Stop and StopLimit orders: buy stops activate when a
+	// trade prints at or above it, sell stops at or below it.
+	StopPrice Decimal
+
+	// Trailing stops. Either TrailingAmount (absolute) or TrailingPercent (a
+	// fraction, 0.01 = 1%) keeps StopPrice that far behind the best price traded
+	// since placement, below it for sells and above it for buys. StopPrice then
+	// holds the current trigger and may be left zero on placement if the book
+	// has traded. A StopLimit's limit price moves along with its trigger.
+	TrailingAmount  Decimal
+	TrailingPercent Decimal
+
+	// Market order protection. ProtectionPrice is the worst price the order may
+	// trade at; MaxSlippage is a fraction (0.01 = 1%) applied to the best
+	// opposite price on arrival. Zero disables either bound, and when both are
+	// set the tighter one wins.
+	ProtectionPrice Decimal
+	MaxSlippage     Decimal
+
+	// DisplayQuantity makes a resting limit order an iceberg: only this much is
+	// visible and matchable at a time, and the rest is held in reserve.
+	DisplayQuantity Decimal
+
+	// Pegged limit orders take their price from Peg plus PegOffset (which may
+	// be negative) and are re-priced whenever the reference moves. PegCap, if
+	// set, is the highest price a pegged buy may reach and the lowest for a
+	// sell. Price then holds the current pegged price. A pegged order cannot
+	// use PostOnlyReprice, which would move it off its peg on every repeg.
+	Peg       PegReference
+	PegOffset Decimal
+	PegCap    Decimal
+
+	visible Decimal // Current iceberg slice
+}
+
+// rests reports whether an unfilled remainder of the order joins the book.
+func (o *Order) rests() bool {
+	return o.Type == Limit && (o.TimeInForce == GTC || o.TimeInForce == GTD)
+}
+
+func (o *Order) isStop() bool {
+	return o.Type == Stop || o.Type == StopLimit
+}
+
+func (o *Order) isTrailing() bool {
+	return o.TrailingAmount.IsPositive() || o.TrailingPercent.IsPositive()
+}
+
+func (o *Order) isIceberg() bool {
+	return o.DisplayQuantity.IsPositive()
+}
+
+// displayed returns the quantity that is visible in the book and can be taken
+// before the order has to be replenished.
+func (o *Order) displayed() Decimal {
+	if o.isIceberg() {
+		return o.visible
+	}
+	return o.Quantity
+}
@@ -0,0 +1,252 @@
+package orderbook
+
+import "time"
+
+// OrderList links orders so that one cancels the others, as in Binance OCO: as
+// soon as any member trades, is triggered or leaves the book, the rest of the
+// list is canceled. Members are resting limit orders or stop orders on the same
+// side, none of them pegged. The list is placed atomically: either every
+// member is accepted or all are rejected.
+type OrderList struct {
+	ID     string
+	Orders []Order
+}
+
+type ListStatus int
+
+const (
+	ListExecuting ListStatus = iota
+	ListAllDone
+	ListRejected
+)
+
+func (s ListStatus) String() string {
+	switch s {
+	case ListExecuting:
+		return "Executing"
+	case ListAllDone:
+		return "AllDone"
+	case ListRejected:
+		return "Rejected"
+	}
+	return "Unknown"
+}
+
+func (s ListStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *ListStatus) UnmarshalText(text []byte) error {
+	for status := ListExecuting; status <= ListRejected; status++ {
+		if status.String() == string(text) {
+			*s = status
+			return nil
+		}
+	}
+	return ErrInvalidListStatus
+}
+
+type orderList struct {
+	id        string
+	open      []string        // Members not yet done, in list order
+	triggered bool            // A member has traded, triggered or left the book
+	canceled  map[string]bool // Triggered stops canceled before their activation
+}
+
+func (ol *orderList) member(orderID string) bool {
+	for _, id := range ol.open {
+		if id == orderID {
+			return true
+		}
+	}
+	return false
+}
+
+func (ol *orderList) close(orderID string) {
+	for i, id := range ol.open {
+		if id == orderID {
+			ol.open = append(ol.open[:i], ol.open[i+1:]...)
+			return
+		}
+	}
+}
+
+// PlaceOrderList places every order of the list or none of them. Limit
+// members are made post-only and must not cross the book, and stop members
+// must not be triggered by the last trade price, so placing a list never
+// trades.
+func (ob *OrderBook) PlaceOrderList(orders OrderList) ([]ExecutionReport, error) {
+	ob.Lock()
+	defer ob.Unlock()
+	ob.begin(time.Now())
+
+	return ob.placeOrderList(orders)
+}
+
+func (ob *OrderBook) placeOrderList(orders OrderList) ([]ExecutionReport, error) {
+	defer ob.repeg()
+
+	members := make([]*Order, len(orders.Orders))
+	for i := range orders.Orders {
+		order := orders.Orders[i]
+		order.ListID = orders.ID
+		if order.Timestamp.IsZero() {
+			order.Timestamp = ob.now
+		}
+		if order.Type == Limit && order.PostOnly == PostOnlyOff {
+			order.PostOnly = PostOnlyReject
+		}
+		members[i] = &order
+	}
+
+	if err := ob.checkOrderList(orders.ID, members); err != nil {
+		reports := make([]ExecutionReport, len(members))
+		for i, order := range members {
+			reports[i], _ = ob.reject(order, err)
+		}
+		ob.emit(Event{Type: EventListStatus, ListID: orders.ID, ListStatus: ListRejected, Reason: err.Error()})
+		return reports, err
+	}
+
+	ol := &orderList{id: orders.ID, canceled: make(map[string]bool)}
+	for _, order := range members {
+		ol.open = append(ol.open, order.ID)
+	}
+	ob.lists[orders.ID] = ol
+	ob.emit(Event{Type: EventListStatus, ListID: orders.ID, ListStatus: ListExecuting})
+
+	reports := make([]ExecutionReport, len(members))
+	for i, order := range members {
+		ob.emitOrder(EventOrderAccepted, order, "")
+		if order.isStop() {
+			ob.stops.add(order)
+			ob.trackExpiry(order)
+			reports[i] = ExecutionReport{OrderID: order.ID, Status: StatusNew, Price: order.Price, RemainingQuantity: order.Quantity}
+			continue
+		}
+		reports[i] = ob.execute(order, order.Price)
+	}
+	return reports, nil
+}
+
+// checkOrderList runs every check a member could fail before any of them is
+// placed.
+func (ob *OrderBook) checkOrderList(listID string, members []*Order) error {
+	if err := ob.session.admits(); err != nil {
+		return err
+	}
+	if listID == "" || len(members) < 2 {
+		return ErrInvalidOrderList
+	}
+	if _, exists := ob.lists[listID]; exists {
+		return ErrDuplicateOrderList
+	}
+
+	seen := make(map[string]bool)
+	for _, order := range members {
+		if err := validate(order); err != nil {
+			return err
+		}
+		if ob.exists(order.ID) || seen[order.ID] {
+			return ErrDuplicateOrder
+		}
+		seen[order.ID] = true
+
+		if order.Side != members[0].Side || order.pegged() || !order.isStop() && !order.rests() {
+			return ErrInvalidOrderList
+		}
+		if order.isStop() {
+			if order.isTrailing() {
+				ob.trailStop(order, ob.lastPrice)
+			}
+			if !order.StopPrice.IsPositive() {
+				return ErrInvalidStopPrice
+			}
+			if !ob.lastPrice.IsZero() && reached(order.Side, order.StopPrice, ob.lastPrice) {
+				return ErrInvalidOrderList
+			}
+		} else if ob.wouldCross(order) {
+			return ErrPostOnlyWouldCross
+		} else if total, ok := listQuantity(members, order.Price); !ok || !ob.levelFits(order.Side, order.Price, total) {
+			return ErrLevelFull
+		}
+		if err := ob.checkBand(order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listQuantity is the quantity of the limit members resting at price. It
+// reports false if that does not fit in a Decimal.
+func listQuantity(members []*Order, price Decimal) (Decimal, bool) {
+	total := Decimal{}
+	for _, order := range members {
+		if !order.isStop() && order.Price.Equal(price) {
+			var ok bool
+			if total, ok = add(total, order.Quantity); !ok {
+				return Decimal{}, false
+			}
+		}
+	}
+	return total, true
+}
+
+// settleList applies the list contingency after a member traded, was
+// triggered or, if done, left the book for good: the first such event cancels
+// the other members, and the list is done once no member is left. Orders
+// that carry the list's ID without being an open member are ignored.
+func (ob *OrderBook) settleList(order *Order, done bool) {
+	ol, found := ob.lists[order.ListID]
+	if !found || !ol.member(order.ID) {
+		return
+	}
+	if done {
+		ol.close(order.ID)
+	}
+
+	if !ol.triggered {
+		ol.triggered = true
+		for _, id := range append([]string(nil), ol.open...) {
+			if id != order.ID {
+				ob.cancelMember(ol, id)
+			}
+		}
+	}
+
+	if len(ol.open) == 0 && ob.lists[ol.id] == ol {
+		delete(ob.lists, ol.id)
+		ob.emit(Event{Type: EventListStatus, ListID: ol.id, ListStatus: ListAllDone})
+	}
+}
+
+// cancelMember cancels a member wherever it is. A stop that has been
+// triggered but not activated yet is canceled by activateStops.
+func (ob *OrderBook) cancelMember(ol *orderList, orderID string) {
+	var order *Order
+	if e, found := ob.orders[orderID]; found {
+		order = ob.remove(e)
+	} else if e, found := ob.stops.orders[orderID]; found {
+		order = ob.stops.remove(e)
+	} else {
+		ol.canceled[orderID] = true
+		return
+	}
+	ob.emitOrder(EventOrderCanceled, order, ReasonOrderList)
+}
+
+// activateMember settles the list of a triggered stop before it is executed,
+// and reports false if the stop was canceled by the list meanwhile.
+func (ob *OrderBook) activateMember(order *Order) bool {
+	ol, found := ob.lists[order.ListID]
+	if !found {
+		return true
+	}
+	if ol.canceled[order.ID] {
+		delete(ol.canceled, order.ID)
+		ob.emitOrder(EventOrderCanceled, order, ReasonOrderList)
+		return false
+	}
+	ob.settleList(order, false)
+	return true
+}
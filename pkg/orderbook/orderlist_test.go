@@ -0,0 +1,267 @@
+package orderbook
+
+import "testing"
+
+func TestOrderList(t *testing.T) {
+	oco := func() OrderList {
+		return OrderList{ID: "oco", Orders: []Order{
+			{ID: "l1", Side: Ask, Price: MustDecimal("110"), Quantity: MustDecimal("2")},
+			{ID: "s1", Side: Ask, Type: Stop, StopPrice: MustDecimal("95"), Quantity: MustDecimal("2")},
+		}}
+	}
+
+	tests := []struct {
+		name         string
+		list         OrderList
+		action       func(ob *OrderBook)
+		wantCanceled []string
+		wantDone     bool
+	}{
+		{
+			name: "partial fill cancels the stop",
+			list: oco(),
+			action: func(ob *OrderBook) {
+				ob.PlaceOrder(Order{ID: "t1", Side: Bid, Price: MustDecimal("110"), Quantity: MustDecimal("1")})
+			},
+			wantCanceled: []string{"s1"},
+		},
+		{
+			name: "trigger cancels the limit",
+			list: oco(),
+			action: func(ob *OrderBook) {
+				ob.PlaceOrder(Order{ID: "a2", Side: Ask, Price: MustDecimal("95"), Quantity: MustDecimal("1")})
+				ob.PlaceOrder(Order{ID: "t1", Side: Bid, Price: MustDecimal("95"), Quantity: MustDecimal("1")})
+			},
+			wantCanceled: []string{"l1"},
+			wantDone:     true,
+		},
+		{
+			name:         "cancel cancels the list",
+			list:         oco(),
+			action:       func(ob *OrderBook) { ob.CancelOrder("s1") },
+			wantCanceled: []string{"s1", "l1"},
+			wantDone:     true,
+		},
+		{
+			name: "stops triggered together",
+			list: OrderList{ID: "oco", Orders: []Order{
+				{ID: "s1", Side: Ask, Type: Stop, StopPrice: MustDecimal("95"), Quantity: MustDecimal("1")},
+				{ID: "s2", Side: Ask, Type: Stop, StopPrice: MustDecimal("96"), Quantity: MustDecimal("1")},
+			}},
+			action: func(ob *OrderBook) {
+				ob.PlaceOrder(Order{ID: "a2", Side: Ask, Price: MustDecimal("94"), Quantity: MustDecimal("1")})
+				ob.PlaceOrder(Order{ID: "t1", Side: Bid, Price: MustDecimal("94"), Quantity: MustDecimal("1")})
+			},
+			wantCanceled: []string{"s1"},
+			wantDone:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook()
+			ob.PlaceOrder(Order{ID: "a0", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+			ob.PlaceOrder(Order{ID: "b0", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+			ob.PlaceOrder(Order{ID: "bid", Side: Bid, Price: MustDecimal("50"), Quantity: MustDecimal("10")})
+
+			var canceled []string
+			var statuses []ListStatus
+			ob.Subscribe(ListenerFunc(func(e Event) {
+				switch {
+				case e.Type == EventOrderCanceled && e.ListID == "oco":
+					canceled = append(canceled, e.OrderID)
+				case e.Type == EventListStatus:
+					statuses = append(statuses, e.ListStatus)
+				}
+			}))
+
+			reports, err := ob.PlaceOrderList(tt.list)
+			if err != nil || len(reports) != len(tt.list.Orders) {
+				t.Fatalf("PlaceOrderList() = %+v, %v", reports, err)
+			}
+			if order, _ := ob.GetOrder(tt.list.Orders[0].ID); order.ListID != "oco" {
+				t.Errorf("GetOrder() ListID = %q, want oco", order.ListID)
+			}
+
+			tt.action(ob)
+
+			if len(canceled) != len(tt.wantCanceled) {
+				t.Fatalf("canceled = %v, want %v", canceled, tt.wantCanceled)
+			}
+			for i := range canceled {
+				if canceled[i] != tt.wantCanceled[i] {
+					t.Errorf("canceled = %v, want %v", canceled, tt.wantCanceled)
+				}
+				if order, found := ob.GetOrder(canceled[i]); found {
+					t.Errorf("GetOrder(%s) = %+v, want canceled", canceled[i], order)
+				}
+			}
+
+			wantStatuses := []ListStatus{ListExecuting}
+			if tt.wantDone {
+				wantStatuses = append(wantStatuses, ListAllDone)
+			}
+			if len(statuses) != len(wantStatuses) || statuses[len(statuses)-1] != wantStatuses[len(wantStatuses)-1] {
+				t.Errorf("list statuses = %v, want %v", statuses, wantStatuses)
+			}
+		})
+	}
+}
+
+func TestOrderListRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		orders []Order
+		want   error
+	}{
+		{"single order", []Order{
+			{ID: "l1", Side: Ask, Price: MustDecimal("110"), Quantity: MustDecimal("1")},
+		}, ErrInvalidOrderList},
+		{"limit crosses", []Order{
+			{ID: "l1", Side: Ask, Price: MustDecimal("99"), Quantity: MustDecimal("1")},
+			{ID: "s1", Side: Ask, Type: Stop, StopPrice: MustDecimal("95"), Quantity: MustDecimal("1")},
+		}, ErrPostOnlyWouldCross},
+		{"stop already triggered", []Order{
+			{ID: "l1", Side: Ask, Price: MustDecimal("110"), Quantity: MustDecimal("1")},
+			{ID: "s1", Side: Ask, Type: Stop, StopPrice: MustDecimal("101"), Quantity: MustDecimal("1")},
+		}, ErrInvalidOrderList},
+		{"mixed sides", []Order{
+			{ID: "l1", Side: Ask, Price: MustDecimal("110"), Quantity: MustDecimal("1")},
+			{ID: "s1", Side: Bid, Type: Stop, StopPrice: MustDecimal("105"), Quantity: MustDecimal("1")},
+		}, ErrInvalidOrderList},
+		{"pegged member", []Order{
+			{ID: "l1", Side: Ask, Peg: PegBestAsk, PegOffset: MustDecimal("10"), Quantity: MustDecimal("1")},
+			{ID: "s1", Side: Ask, Type: Stop, StopPrice: MustDecimal("95"), Quantity: MustDecimal("1")},
+		}, ErrInvalidOrderList},
+		{"duplicate member", []Order{
+			{ID: "l1", Side: Ask, Price: MustDecimal("110"), Quantity: MustDecimal("1")},
+			{ID: "l1", Side: Ask, Type: Stop, StopPrice: MustDecimal("95"), Quantity: MustDecimal("1")},
+		}, ErrDuplicateOrder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook()
+			ob.PlaceOrder(Order{ID: "a0", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+			ob.PlaceOrder(Order{ID: "b0", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("2")})
+
+			reports, err := ob.PlaceOrderList(OrderList{ID: "oco", Orders: tt.orders})
+			if err != tt.want {
+				t.Fatalf("PlaceOrderList() error = %v, want %v", err, tt.want)
+			}
+			for _, report := range reports {
+				if report.Status != StatusRejected {
+					t.Errorf("report %s status = %v, want Rejected", report.OrderID, report.Status)
+				}
+			}
+			if _, found := ob.GetOrder("l1"); found {
+				t.Errorf("l1 should not have been placed")
+			}
+		})
+	}
+}
+
+func TestOrderListIgnoresNonMembers(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceOrder(Order{ID: "a0", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+	ob.PlaceOrder(Order{ID: "b0", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+
+	list := OrderList{ID: "oco", Orders: []Order{
+		{ID: "l1", Side: Ask, Price: MustDecimal("110"), Quantity: MustDecimal("1")},
+		{ID: "s1", Side: Ask, Type: Stop, StopPrice: MustDecimal("95"), Quantity: MustDecimal("1")},
+	}}
+	if _, err := ob.PlaceOrderList(list); err != nil {
+		t.Fatalf("PlaceOrderList() error = %v", err)
+	}
+
+	var canceled []string
+	ob.Subscribe(ListenerFunc(func(e Event) {
+		if e.Type == EventOrderCanceled {
+			canceled = append(canceled, e.OrderID)
+		}
+	}))
+
+	if _, err := ob.PlaceOrderList(list); err != ErrDuplicateOrderList {
+		t.Errorf("PlaceOrderList(again) error = %v, want %v", err, ErrDuplicateOrderList)
+	}
+	if _, err := ob.PlaceOrder(Order{ID: "x1", ListID: "oco", Side: Bid, Price: MustDecimal("90")}); err != ErrInvalidQuantity {
+		t.Errorf("PlaceOrder(invalid) error = %v, want %v", err, ErrInvalidQuantity)
+	}
+	ob.PlaceOrder(Order{ID: "x2", ListID: "oco", Side: Bid, Price: MustDecimal("90"), Quantity: MustDecimal("1")})
+	if _, err := ob.CancelOrder("x2"); err != nil {
+		t.Fatalf("CancelOrder(x2) error = %v", err)
+	}
+
+	if len(canceled) != 1 || canceled[0] != "x2" {
+		t.Errorf("canceled = %v, want only x2", canceled)
+	}
+	for _, id := range []string{"l1", "s1"} {
+		if _, found := ob.GetOrder(id); !found {
+			t.Errorf("GetOrder(%s) not found, want the list still live", id)
+		}
+	}
+}
+
+func TestOrderListFillOrKill(t *testing.T) {
+	ob := NewOrderBook()
+	list := OrderList{ID: "oco", Orders: []Order{
+		{ID: "l1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("5")},
+		{ID: "l2", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("5")},
+	}}
+	if _, err := ob.PlaceOrderList(list); err != nil {
+		t.Fatalf("PlaceOrderList() error = %v", err)
+	}
+
+	// Trading with l1 would cancel l2, so only 5 are there to fill.
+	if _, err := ob.PlaceOrder(Order{ID: "fok", Side: Bid, Price: MustDecimal("101"), Quantity: MustDecimal("10"), TimeInForce: FOK}); err != ErrFillOrKill {
+		t.Errorf("PlaceOrder(fok) error = %v, want %v", err, ErrFillOrKill)
+	}
+	for _, id := range []string{"l1", "l2"} {
+		if _, found := ob.GetOrder(id); !found {
+			t.Errorf("GetOrder(%s) not found, want the list untouched", id)
+		}
+	}
+
+	report, err := ob.PlaceOrder(Order{ID: "fok2", Side: Bid, Price: MustDecimal("101"), Quantity: MustDecimal("5"), TimeInForce: FOK})
+	if err != nil || report.Status != StatusFilled {
+		t.Errorf("PlaceOrder(fok2) = %+v, %v, want filled", report, err)
+	}
+}
+
+func TestOrderListCancelAll(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceOrder(Order{ID: "a0", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+	ob.PlaceOrder(Order{ID: "b0", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+
+	list := OrderList{ID: "oco", Orders: []Order{
+		{ID: "l1", Side: Ask, Price: MustDecimal("110"), Quantity: MustDecimal("1")},
+		{ID: "s1", Side: Ask, Type: Stop, StopPrice: MustDecimal("95"), Quantity: MustDecimal("1")},
+	}}
+	if _, err := ob.PlaceOrderList(list); err != nil {
+		t.Fatalf("PlaceOrderList() error = %v", err)
+	}
+
+	var reasons []string
+	var done bool
+	ob.Subscribe(ListenerFunc(func(e Event) {
+		switch e.Type {
+		case EventOrderCanceled:
+			reasons = append(reasons, e.Reason)
+		case EventListStatus:
+			done = e.ListStatus == ListAllDone
+		}
+	}))
+
+	canceled := ob.CancelAll()
+	if len(canceled) != 2 || canceled[0].ID != "l1" || canceled[1].ID != "s1" {
+		t.Errorf("CancelAll() = %+v, want l1 and s1", canceled)
+	}
+	for _, reason := range reasons {
+		if reason != ReasonMassCancel {
+			t.Errorf("cancel reasons = %v, want only %s", reasons, ReasonMassCancel)
+		}
+	}
+	if !done {
+		t.Errorf("list not reported done")
+	}
+}
@@ -0,0 +1,152 @@
+package orderbook
+
+// PegReference is the price a pegged order follows.
+type PegReference int
+
+const (
+	PegNone    PegReference = iota
+	PegBestBid              // Best bid
+	PegBestAsk              // Best ask
+	PegMid                  // Midpoint of the best bid and ask, rounded away from the opposite side
+)
+
+func (p PegReference) String() string {
+	switch p {
+	case PegNone:
+		return "None"
+	case PegBestBid:
+		return "BestBid"
+	case PegBestAsk:
+		return "BestAsk"
+	case PegMid:
+		return "Mid"
+	}
+	return "Unknown"
+}
+
+func (p PegReference) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *PegReference) UnmarshalText(text []byte) error {
+	for peg := PegNone; peg <= PegMid; peg++ {
+		if peg.String() == string(text) {
+			*p = peg
+			return nil
+		}
+	}
+	return ErrInvalidPeg
+}
+
+// pegged reports whether the order's price follows a reference.
+func (o *Order) pegged() bool {
+	return o.Peg != PegNone
+}
+
+// pegReference returns the best price on side among orders that are not
+// pegged themselves, so that pegged orders never follow each other.
+func (ob *OrderBook) pegReference(side OrderSide) (Decimal, bool) {
+	for iter := ob.tree(side).Iterator(); iter.Next(); {
+		for e := iter.Value().Front(); e != nil; e = e.Next() {
+			if !e.Value.(*Order).pegged() {
+				return iter.Key(), true
+			}
+		}
+	}
+	return Decimal{}, false
+}
+
+// pegPrice derives the price of a pegged order from its reference, offset and
+// cap. It reports false while the reference is missing.
+func (ob *OrderBook) pegPrice(order *Order) (Decimal, bool) {
+	var reference Decimal
+	switch order.Peg {
+	case PegBestBid, PegBestAsk:
+		side := Bid
+		if order.Peg == PegBestAsk {
+			side = Ask
+		}
+		var found bool
+		if reference, found = ob.pegReference(side); !found {
+			return Decimal{}, false
+		}
+	case PegMid:
+		bid, bidFound := ob.pegReference(Bid)
+		ask, askFound := ob.pegReference(Ask)
+		if !bidFound || !askFound {
+			return Decimal{}, false
+		}
+		mid, err := bid.Add(ask).Mul(half)
+		if err != nil {
+			return Decimal{}, false
+		}
+		reference = roundToTick(mid, ob.tickSize, order.Side == Ask)
+	default:
+		return Decimal{}, false
+	}
+
+	price := reference.Add(order.PegOffset)
+	if !order.PegCap.IsZero() && !crosses(order.Side, order.PegCap, price) {
+		price = order.PegCap
+	}
+	return price, price.IsPositive()
+}
+
+func (ob *OrderBook) unpeg(order *Order) {
+	for e := ob.pegs.Front(); e != nil; e = e.Next() {
+		if e.Value.(*Order) == order {
+			ob.pegs.Remove(e)
+			return
+		}
+	}
+}
+
+// repeg moves every pegged order whose reference has changed to its new price.
+// A moved order joins the back of its new level and may trade like an amended
+// order; such trades can move the references again, so repeg runs until the
+// book settles. It runs at the end of every write.
+//
+// The trades of repegged orders, and the stops they activate, happen after
+// the write that moved the reference and are not part of its report. Callers
+// only see them through events.
+func (ob *OrderBook) repeg() {
+	if ob.pegs.Len() == 0 || ob.session.admits() != nil {
+		return
+	}
+
+	for moved := true; moved; {
+		moved = false
+
+		var orders []*Order
+		for e := ob.pegs.Front(); e != nil; e = e.Next() {
+			orders = append(orders, e.Value.(*Order))
+		}
+		for _, order := range orders {
+			e, resting := ob.orders[order.ID]
+			if !resting || e.Value.(*Order) != order {
+				continue
+			}
+			price, found := ob.pegPrice(order)
+			if !found || price.Equal(order.Price) {
+				continue
+			}
+
+			repegged := *order
+			repegged.Price = price
+			repegged.Timestamp = ob.now
+			limit, err := ob.admit(&repegged)
+			if err != nil {
+				continue
+			}
+
+			ob.remove(e)
+			ob.emitOrder(EventOrderAmended, &repegged, ReasonRepeg)
+			ob.execute(&repegged, limit)
+			moved = true
+		}
+
+		if len(ob.activateStops()) > 0 {
+			moved = true
+		}
+	}
+}
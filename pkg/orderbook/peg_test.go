@@ -0,0 +1,183 @@
+package orderbook
+
+import "testing"
+
+func TestPeggedOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		peg       Order
+		wantPrice string // Price on placement, best bid 100 and best ask 104
+		move      Order  // Order that moves the reference
+		wantMoved string // Price after the move
+	}{
+		{
+			name:      "best bid",
+			peg:       Order{Side: Bid, Peg: PegBestBid},
+			wantPrice: "100",
+			move:      Order{Side: Bid, Price: MustDecimal("101")},
+			wantMoved: "101",
+		},
+		{
+			name:      "best ask with negative offset",
+			peg:       Order{Side: Ask, Peg: PegBestAsk, PegOffset: MustDecimal("-1")},
+			wantPrice: "103",
+			move:      Order{Side: Ask, Price: MustDecimal("103")},
+			wantMoved: "102",
+		},
+		{
+			name:      "mid rounds down for a buy",
+			peg:       Order{Side: Bid, Peg: PegMid},
+			wantPrice: "102",
+			move:      Order{Side: Ask, Price: MustDecimal("103")},
+			wantMoved: "101",
+		},
+		{
+			name:      "mid rounds up for a sell",
+			peg:       Order{Side: Ask, Peg: PegMid},
+			wantPrice: "102",
+			move:      Order{Side: Ask, Price: MustDecimal("103")},
+			wantMoved: "102",
+		},
+		{
+			name:      "cap holds a buy",
+			peg:       Order{Side: Bid, Peg: PegBestBid, PegCap: MustDecimal("100")},
+			wantPrice: "100",
+			move:      Order{Side: Bid, Price: MustDecimal("102")},
+			wantMoved: "100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook(WithTickSize(MustDecimal("1")))
+			ob.PlaceOrder(Order{ID: "bid", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+			ob.PlaceOrder(Order{ID: "ask", Side: Ask, Price: MustDecimal("104"), Quantity: MustDecimal("1")})
+
+			tt.peg.ID, tt.peg.Quantity = "peg", MustDecimal("1")
+			if _, err := ob.PlaceOrder(tt.peg); err != nil {
+				t.Fatalf("PlaceOrder(peg) error = %v", err)
+			}
+			if order, _ := ob.GetOrder("peg"); !order.Price.Equal(MustDecimal(tt.wantPrice)) {
+				t.Errorf("price on placement = %s, want %s", order.Price, tt.wantPrice)
+			}
+
+			tt.move.ID, tt.move.Quantity = "move", MustDecimal("1")
+			if _, err := ob.PlaceOrder(tt.move); err != nil {
+				t.Fatalf("PlaceOrder(move) error = %v", err)
+			}
+			if order, _ := ob.GetOrder("peg"); !order.Price.Equal(MustDecimal(tt.wantMoved)) {
+				t.Errorf("price after move = %s, want %s", order.Price, tt.wantMoved)
+			}
+		})
+	}
+}
+
+func TestPeggedOrderRequeue(t *testing.T) {
+	ob := NewOrderBook()
+	var amended []Event
+	ob.Subscribe(ListenerFunc(func(e Event) {
+		if e.Type == EventOrderAmended {
+			amended = append(amended, e)
+		}
+	}))
+
+	ob.PlaceOrder(Order{ID: "b1", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+	ob.PlaceOrder(Order{ID: "b2", Side: Bid, Price: MustDecimal("99"), Quantity: MustDecimal("1")})
+	ob.PlaceOrder(Order{ID: "peg", Side: Bid, Peg: PegBestBid, Quantity: MustDecimal("1")})
+
+	// The reference falls back to 99, where the pegged order queues behind b2.
+	if _, err := ob.CancelOrder("b1"); err != nil {
+		t.Fatalf("CancelOrder(b1) error = %v", err)
+	}
+	if len(amended) != 1 || amended[0].OrderID != "peg" || amended[0].Reason != ReasonRepeg || !amended[0].Price.Equal(MustDecimal("99")) {
+		t.Fatalf("amended events = %+v, want peg repegged to 99", amended)
+	}
+
+	report, err := ob.PlaceOrder(Order{ID: "sell", Side: Ask, Type: Market, Quantity: MustDecimal("1")})
+	if err != nil || len(report.Trades) != 1 || report.Trades[0].MakerOrderID != "b2" {
+		t.Errorf("market sell = %+v, %v, want a trade with b2 first", report, err)
+	}
+
+	// Without a reference the pegged order keeps its last price and still
+	// trades.
+	ob.PlaceOrder(Order{ID: "sell2", Side: Ask, Type: Market, Quantity: MustDecimal("1")})
+	if _, found := ob.GetOrder("peg"); found {
+		t.Errorf("peg still resting after being sold into")
+	}
+}
+
+func TestPeggedOrderTradesWhenReferenceCrosses(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceOrder(Order{ID: "ask", Side: Ask, Price: MustDecimal("105"), Quantity: MustDecimal("1")})
+	ob.PlaceOrder(Order{ID: "bid", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+	ob.PlaceOrder(Order{ID: "peg", Side: Bid, Peg: PegBestBid, PegOffset: MustDecimal("3"), Quantity: MustDecimal("1")})
+
+	if order, _ := ob.GetOrder("peg"); !order.Price.Equal(MustDecimal("103")) {
+		t.Fatalf("peg price = %s, want 103", order.Price)
+	}
+
+	// Repegged to 105, the order takes the ask like an amended order would.
+	ob.PlaceOrder(Order{ID: "bid2", Side: Bid, Price: MustDecimal("102"), Quantity: MustDecimal("1")})
+	for _, id := range []string{"ask", "peg"} {
+		if order, found := ob.GetOrder(id); found {
+			t.Errorf("GetOrder(%s) = %+v, want it filled", id, order)
+		}
+	}
+	if last := ob.lastPrice; !last.Equal(MustDecimal("105")) {
+		t.Errorf("last price = %s, want 105", last)
+	}
+}
+
+func TestPeggedOrderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   Order
+		wantErr error
+	}{
+		{
+			name:    "market",
+			order:   Order{Side: Bid, Type: Market, Peg: PegBestBid},
+			wantErr: ErrInvalidPeg,
+		},
+		{
+			name:    "negative cap",
+			order:   Order{Side: Bid, Peg: PegBestBid, PegCap: MustDecimal("-1")},
+			wantErr: ErrInvalidPeg,
+		},
+		{
+			name:    "post-only reprice",
+			order:   Order{Side: Bid, Peg: PegBestBid, PostOnly: PostOnlyReprice},
+			wantErr: ErrInvalidPeg,
+		},
+		{
+			name:    "no reference",
+			order:   Order{Side: Ask, Peg: PegBestAsk},
+			wantErr: ErrNoPegReference,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook()
+			ob.PlaceOrder(Order{ID: "bid", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+
+			tt.order.ID, tt.order.Quantity = "peg", MustDecimal("1")
+			if _, err := ob.PlaceOrder(tt.order); err != tt.wantErr {
+				t.Errorf("PlaceOrder() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("amend price", func(t *testing.T) {
+		ob := NewOrderBook()
+		ob.PlaceOrder(Order{ID: "bid", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+		ob.PlaceOrder(Order{ID: "peg", Side: Bid, Peg: PegBestBid, Quantity: MustDecimal("2")})
+
+		if _, err := ob.AmendOrder("peg", MustDecimal("99"), Decimal{}); err != ErrInvalidPeg {
+			t.Errorf("AmendOrder(price) error = %v, want %v", err, ErrInvalidPeg)
+		}
+		if _, err := ob.AmendOrder("peg", Decimal{}, MustDecimal("1")); err != nil {
+			t.Errorf("AmendOrder(quantity) error = %v", err)
+		}
+	})
+}
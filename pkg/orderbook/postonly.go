@@ -0,0 +1,26 @@
+package orderbook
+
+// applyPostOnly checks a post-only order against the best opposite price. An
+// order that would cross is rejected, or with PostOnlyReprice moved to one
+// tick behind the opposite best so that it rests as a maker.
+func (ob *OrderBook) applyPostOnly(order *Order) error {
+	if !ob.wouldCross(order) {
+		return nil
+	}
+	best, _ := ob.bestPrice(order.Side.Opposite())
+
+	if order.PostOnly != PostOnlyReprice || !ob.tickSize.IsPositive() {
+		return ErrPostOnlyWouldCross
+	}
+
+	price := best.Add(ob.tickSize)
+	if order.Side == Bid {
+		price = best.Sub(ob.tickSize)
+	}
+	if !price.IsPositive() {
+		return ErrPostOnlyWouldCross
+	}
+
+	order.Price = price
+	return nil
+}
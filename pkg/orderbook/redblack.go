@@ -1,76 +1,151 @@
 package orderbook
 
 import (
+	"container/heap"
 	"container/list"
-	"fmt"
 	"sync"
 	"time"
 
 	"github.com/emirpasic/gods/v2/trees/redblacktree"
 )
 
-type OrderSide int
+type OrderBook struct {
+	Bids *redblacktree.Tree[Decimal, *list.List] // Buy orders, descending order
+	Asks *redblacktree.Tree[Decimal, *list.List] // Sell orders, ascending order
+	sync.RWMutex
 
-const (
-	Bid OrderSide = iota
-	Ask
-)
+	orders    map[string]*list.Element // Resting orders by ID
+	stops     *stopBook
+	expiries  expiryQueue
+	tradeID   uint64
+	lastPrice Decimal
+	tickSize  Decimal
+	lotSize   Decimal
+	policy    MatchingPolicy
+	stp       SelfTradePrevention
+	sequence  uint64    // Number of write operations applied to the book
+	now       time.Time // Time of the write operation being applied
+
+	band        PriceBand
+	breaker     CircuitBreaker
+	window      priceWindow
+	haltedUntil time.Time // End of a circuit breaker halt
+	session     SessionState
+
+	lists map[string]*orderList // Order lists with members left
+	pegs  *list.List            // Resting pegged orders in arrival order
+
+	listeners      []subscription
+	subscriptionID uint64
+	eventSequence  uint64
+}
 
-func (s OrderSide) String() string {
-	if s == Bid {
-		return "Bid"
+type Option func(*OrderBook)
+
+// WithTickSize sets the minimum price increment of the book. It is used to
+// re-price post-only orders one tick away from the opposite side.
+func WithTickSize(tick Decimal) Option {
+	return func(ob *OrderBook) {
+		ob.tickSize = tick
 	}
-	return "Ask"
 }
 
-type Order struct {
-	ID        string
-	Side      OrderSide
-	Price     float64
-	Quantity  float64
-	Timestamp time.Time
+// WithLotSize sets the minimum quantity increment of the book. Pro-rata
+// allocations are rounded down to it.
+func WithLotSize(lot Decimal) Option {
+	return func(ob *OrderBook) {
+		ob.lotSize = lot
+	}
 }
 
-type OrderBook struct {
-	Bids *redblacktree.Tree[float64, *list.List] // Buy orders, descending order
-	Asks *redblacktree.Tree[float64, *list.List] // Sell orders, ascending order
-	sync.RWMutex
+// WithMatchingPolicy sets how an incoming order is shared among the orders
+// resting at a price level. The default is FIFO.
+func WithMatchingPolicy(policy MatchingPolicy) Option {
+	return func(ob *OrderBook) {
+		ob.policy = policy
+	}
 }
 
-func NewOrderBook() *OrderBook {
-	bidComparator := func(a, b float64) int {
-		if a > b {
-			return -1
-		}
-		if a < b {
-			return 1
-		}
-		return 0
+func NewOrderBook(opts ...Option) *OrderBook {
+	bidComparator := func(a, b Decimal) int {
+		return b.Cmp(a)
 	}
 
-	askComparator := func(a, b float64) int {
-		if a < b {
-			return -1
-		}
-		if a > b {
-			return 1
-		}
-		return 0
+	askComparator := func(a, b Decimal) int {
+		return a.Cmp(b)
+	}
+	ob := &OrderBook{
+		Bids:   redblacktree.NewWith[Decimal, *list.List](bidComparator),
+		Asks:   redblacktree.NewWith[Decimal, *list.List](askComparator),
+		orders: make(map[string]*list.Element),
+		stops:  newStopBook(),
+		lists:  make(map[string]*orderList),
+		pegs:   list.New(),
+		policy: FIFO{},
+	}
+	for _, opt := range opts {
+		opt(ob)
 	}
-	return &OrderBook{
-		Bids: redblacktree.NewWith[float64, *list.List](bidComparator),
-		Asks: redblacktree.NewWith[float64, *list.List](askComparator),
+	return ob
+}
+
+func (ob *OrderBook) tree(side OrderSide) *redblacktree.Tree[Decimal, *list.List] {
+	if side == Ask {
+		return ob.Asks
 	}
+	return ob.Bids
 }
 
-func (ob *OrderBook) InsertOrder(order Order) {
+// begin starts a write operation. Every write gets the next sequence number,
+// and everything it does is stamped with now, so replaying the same writes
+// with the same times yields the same book.
+func (ob *OrderBook) begin(now time.Time) {
+	ob.sequence++
+	ob.now = now
+	ob.resume()
+}
+
+// InsertOrder rests a GTC or GTD limit order without matching it, even if it
+// crosses the book; MatchOrders uncrosses it later. It runs the same checks as
+// PlaceOrder that do not depend on matching.
+func (ob *OrderBook) InsertOrder(order Order) error {
 	ob.Lock()
 	defer ob.Unlock()
+	ob.begin(time.Now())
 
-	tree := ob.Bids
-	if order.Side == Ask {
-		tree = ob.Asks
+	order.ListID = ""
+	if order.Timestamp.IsZero() {
+		order.Timestamp = ob.now
+	}
+	if err := validate(&order); err != nil {
+		_, err = ob.reject(&order, err)
+		return err
+	}
+	if !order.rests() || order.pegged() {
+		_, err := ob.reject(&order, ErrInvalidOrderType)
+		return err
+	}
+	if ob.exists(order.ID) {
+		_, err := ob.reject(&order, ErrDuplicateOrder)
+		return err
+	}
+	if err := ob.session.admits(); err != nil {
+		_, err = ob.reject(&order, err)
+		return err
 	}
+	if !ob.levelFits(order.Side, order.Price, order.Quantity) {
+		_, err := ob.reject(&order, ErrLevelFull)
+		return err
+	}
+
+	ob.emitOrder(EventOrderAccepted, &order, "")
+	ob.rest(&order)
+	ob.repeg()
+	return nil
+}
+
+func (ob *OrderBook) rest(order *Order) {
+	tree := ob.tree(order.Side)
 
 	queue, found := tree.Get(order.Price)
 	if !found {
@@ -78,42 +153,222 @@ func (ob *OrderBook) InsertOrder(order Order) {
 		tree.Put(order.Price, queue)
 	}
 
-	queue.PushBack(order)
+	if order.isIceberg() {
+		order.visible = minDecimal(order.DisplayQuantity, order.Quantity)
+	}
+
+	ob.orders[order.ID] = queue.PushBack(order)
+	if order.pegged() {
+		ob.pegs.PushBack(order)
+	}
+	ob.trackExpiry(order)
+	ob.emitLevel(order.Side, order.Price)
+}
+
+// consume takes a fill off a resting order. A fully filled order leaves the
+// book; an iceberg whose visible slice is used up is replenished from its
+// reserve and moves to the back of its price level.
+func (ob *OrderBook) consume(side OrderSide, price Decimal, queue *list.List, e *list.Element, trade *Trade) {
+	order := e.Value.(*Order)
+	order.Quantity = order.Quantity.Sub(trade.Quantity)
+	if order.isIceberg() {
+		order.visible = order.visible.Sub(trade.Quantity)
+	}
+	ob.emitFill(order, trade)
+
+	switch {
+	case order.Quantity.IsZero():
+		ob.unlink(side, price, queue, e)
+		return
+	case order.isIceberg() && order.visible.IsZero():
+		order.visible = minDecimal(order.DisplayQuantity, order.Quantity)
+		queue.MoveToBack(e)
+	}
+	ob.emitLevel(side, price)
+}
+
+func (ob *OrderBook) trackExpiry(order *Order) {
+	if order.TimeInForce == GTD {
+		heap.Push(&ob.expiries, order)
+	}
+}
+
+func (ob *OrderBook) exists(orderID string) bool {
+	_, resting := ob.orders[orderID]
+	_, dormant := ob.stops.orders[orderID]
+	return resting || dormant
+}
+
+// unlink removes the element from its price level and the order index, and
+// drops the level once it is empty.
+func (ob *OrderBook) unlink(side OrderSide, price Decimal, queue *list.List, e *list.Element) {
+	order := e.Value.(*Order)
+	delete(ob.orders, order.ID)
+	if order.pegged() {
+		ob.unpeg(order)
+	}
+	queue.Remove(e)
+	if queue.Len() == 0 {
+		ob.tree(side).Remove(price)
+	}
+	ob.emitLevel(side, price)
+}
+
+// reduce takes quantity off an order without trading it. A resting order
+// leaves the book once nothing is left, and an order reduced to nothing is
+// reported canceled for reason.
+func (ob *OrderBook) reduce(order *Order, quantity Decimal, reason string) {
+	order.Quantity = order.Quantity.Sub(quantity)
+	if order.isIceberg() {
+		order.visible = minDecimal(order.visible, order.Quantity)
+	}
+
+	e, resting := ob.orders[order.ID]
+	resting = resting && e.Value.(*Order) == order
+
+	switch {
+	case order.Quantity.IsZero() && resting:
+		ob.remove(e)
+		ob.emitOrder(EventOrderCanceled, order, reason)
+	case order.Quantity.IsZero():
+		ob.emitOrder(EventOrderCanceled, order, reason)
+	case resting:
+		ob.emitLevel(order.Side, order.Price)
+	}
+}
+
+// remove unlinks a resting order found through the index.
+func (ob *OrderBook) remove(e *list.Element) *Order {
+	order := e.Value.(*Order)
+	queue, _ := ob.tree(order.Side).Get(order.Price)
+	ob.unlink(order.Side, order.Price, queue, e)
+	return order
 }
 
-func (ob *OrderBook) RemoveOrder(side OrderSide, price float64, orderID string) bool {
+func (ob *OrderBook) RemoveOrder(side OrderSide, price Decimal, orderID string) bool {
 	ob.Lock()
 	defer ob.Unlock()
+	ob.begin(time.Now())
 
-	tree := ob.Bids
-	if side == Ask {
-		tree = ob.Asks
+	e, found := ob.orders[orderID]
+	if !found {
+		return false
 	}
 
-	queue, found := tree.Get(price)
-	if !found {
+	order := e.Value.(*Order)
+	if order.Side != side || !order.Price.Equal(price) {
 		return false
 	}
 
-	for e := queue.Front(); e != nil; e = e.Next() {
-		if order, ok := e.Value.(Order); ok && order.ID == orderID {
-			queue.Remove(e)
-			if queue.Len() == 0 {
-				tree.Remove(price)
+	ob.emitOrder(EventOrderCanceled, ob.remove(e), ReasonRequested)
+	ob.repeg()
+	return true
+}
+
+// CancelOrder removes a resting or dormant stop order by ID alone and returns
+// it as it was at the time of cancellation, i.e. with only its unfilled
+// quantity.
+func (ob *OrderBook) CancelOrder(orderID string) (Order, error) {
+	ob.Lock()
+	defer ob.Unlock()
+	ob.begin(time.Now())
+
+	return ob.cancelOrder(orderID)
+}
+
+func (ob *OrderBook) cancelOrder(orderID string) (Order, error) {
+	defer ob.repeg()
+
+	var order *Order
+	if e, found := ob.orders[orderID]; found {
+		order = ob.remove(e)
+	} else if e, found := ob.stops.orders[orderID]; found {
+		order = ob.stops.remove(e)
+	} else {
+		return Order{}, ErrOrderNotFound
+	}
+
+	ob.emitOrder(EventOrderCanceled, order, ReasonRequested)
+	return *order, nil
+}
+
+// CancelAll removes every resting and dormant stop order and returns them,
+// bids before asks and each side in price-time priority, followed by stops.
+func (ob *OrderBook) CancelAll() []Order {
+	ob.Lock()
+	defer ob.Unlock()
+	ob.begin(time.Now())
+
+	return ob.cancelAll()
+}
+
+func (ob *OrderBook) cancelAll() []Order {
+	var elements []*list.Element
+	for _, tree := range []*redblacktree.Tree[Decimal, *list.List]{ob.Bids, ob.Asks} {
+		for iter := tree.Iterator(); iter.Next(); {
+			for e := iter.Value().Front(); e != nil; e = e.Next() {
+				elements = append(elements, e)
+			}
+		}
+	}
+	var stops []*list.Element
+	for _, tree := range []*redblacktree.Tree[Decimal, *list.List]{ob.stops.buys, ob.stops.sells} {
+		for iter := tree.Iterator(); iter.Next(); {
+			for e := iter.Value().Front(); e != nil; e = e.Next() {
+				stops = append(stops, e)
 			}
-			return true
 		}
 	}
-	return false
+
+	// Every member of an order list is canceled here, so the lists must not
+	// cancel each other's members on the way.
+	for _, ol := range ob.lists {
+		ol.triggered = true
+	}
+
+	canceled := make([]Order, 0, len(elements)+len(stops))
+	for _, e := range elements {
+		order := ob.remove(e)
+		ob.emitOrder(EventOrderCanceled, order, ReasonMassCancel)
+		canceled = append(canceled, *order)
+	}
+	for _, e := range stops {
+		order := ob.stops.remove(e)
+		ob.emitOrder(EventOrderCanceled, order, ReasonMassCancel)
+		canceled = append(canceled, *order)
+	}
+	return canceled
+}
+
+// GetOrder returns a copy of a resting or dormant stop order.
+func (ob *OrderBook) GetOrder(orderID string) (Order, bool) {
+	ob.RLock()
+	defer ob.RUnlock()
+
+	return ob.getOrder(orderID)
+}
+
+func (ob *OrderBook) getOrder(orderID string) (Order, bool) {
+	if e, found := ob.orders[orderID]; found {
+		return *e.Value.(*Order), true
+	}
+	if e, found := ob.stops.orders[orderID]; found {
+		return *e.Value.(*Order), true
+	}
+	return Order{}, false
 }
 
-func (ob *OrderBook) MatchOrders() []string {
+// MatchOrders crosses resting bids and asks in price-time priority until the
+// book is no longer crossed. The older order of each pair is the maker and sets
+// the execution price.
+func (ob *OrderBook) MatchOrders() []Trade {
 	ob.Lock()
 	defer ob.Unlock()
+	ob.begin(time.Now())
 
-	var tradeLogs []string
+	var trades []Trade
 
-	for !ob.Bids.Empty() && !ob.Asks.Empty() {
+	for !ob.Bids.Empty() && !ob.Asks.Empty() && ob.session == SessionContinuous {
 		bidIter := ob.Bids.Iterator()
 		askIter := ob.Asks.Iterator()
 		if !bidIter.Next() || !askIter.Next() {
@@ -123,7 +378,7 @@ func (ob *OrderBook) MatchOrders() []string {
 		bidPrice := bidIter.Key()
 		askPrice := askIter.Key()
 
-		if bidPrice < askPrice {
+		if bidPrice.LessThan(askPrice) {
 			break
 		}
 
@@ -132,61 +387,58 @@ func (ob *OrderBook) MatchOrders() []string {
 
 		bidElement := bidQueue.Front()
 		askElement := askQueue.Front()
-		if bidElement == nil || askElement == nil {
+
+		bidOrder := bidElement.Value.(*Order)
+		askOrder := askElement.Value.(*Order)
+
+		maker, taker := bidOrder, askOrder
+		if askOrder.Timestamp.Before(bidOrder.Timestamp) {
+			maker, taker = askOrder, bidOrder
+		}
+
+		if ob.isSelfTrade(maker, taker) {
+			ob.preventSelfTrade(maker, taker)
 			continue
 		}
 
-		bidOrder := bidElement.Value.(Order)
-		askOrder := askElement.Value.(Order)
+		tradeQty := minDecimal(bidOrder.displayed(), askOrder.displayed())
+		trade := ob.newTrade(maker, taker, maker.Price, tradeQty)
+		trades = append(trades, trade)
+
+		ob.consume(Bid, bidPrice, bidQueue, bidElement, &trade)
+		ob.consume(Ask, askPrice, askQueue, askElement, &trade)
+	}
 
-		tradeQty := min(bidOrder.Quantity, askOrder.Quantity)
-		tradeLog := fmt.Sprintf("Trade: %s buys from %s, qty:%.2f, price:%.2f", bidOrder.ID, askOrder.ID, tradeQty, askPrice)
-		tradeLogs = append(tradeLogs, tradeLog)
+	for _, report := range ob.activateStops() {
+		trades = append(trades, report.Trades...)
 	}
-	return tradeLogs
+	ob.repeg()
+	return trades
 }
 
-func (ob *OrderBook) GetBestBid() (float64, float64, bool) {
+// GetBestBid returns the best bid price and the total displayed quantity
+// resting at it.
+func (ob *OrderBook) GetBestBid() (Decimal, Decimal, bool) {
 	ob.RLock()
 	defer ob.RUnlock()
 
-	if ob.Bids.Empty() {
-		return 0, 0, false
-	}
-	iter := ob.Bids.Iterator()
-	if iter.Next() {
-		price := iter.Key()
-		queue := iter.Value()
-		if queue.Front() != nil {
-			order := queue.Front().Value.(Order)
-			return price, order.Quantity, true
-		}
-	}
-	return 0, 0, false
+	return bestLevel(ob.Bids)
 }
 
-func (ob *OrderBook) GetBestAsk() (float64, float64, bool) {
+// GetBestAsk returns the best ask price and the total displayed quantity
+// resting at it.
+func (ob *OrderBook) GetBestAsk() (Decimal, Decimal, bool) {
 	ob.RLock()
 	defer ob.RUnlock()
 
-	if ob.Asks.Empty() {
-		return 0, 0, false
-	}
-	iter := ob.Asks.Iterator()
-	if iter.Next() {
-		price := iter.Key()
-		queue := iter.Value()
-		if queue.Front() != nil {
-			order := queue.Front().Value.(Order)
-			return price, order.Quantity, true
-		}
-	}
-	return 0, 0, false
+	return bestLevel(ob.Asks)
 }
 
-func min(a, b float64) float64 {
-	if a < b {
-		return a
+func bestLevel(tree *redblacktree.Tree[Decimal, *list.List]) (Decimal, Decimal, bool) {
+	iter := tree.Iterator()
+	if !iter.Next() {
+		return Decimal{}, Decimal{}, false
 	}
-	return b
+	level := aggregate(iter.Key(), iter.Value())
+	return level.Price, level.Quantity, true
 }
@@ -0,0 +1,226 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatchOrders(t *testing.T) {
+	base := time.Now()
+
+	tests := []struct {
+		name       string
+		orders     []Order
+		wantTrades []Trade
+		wantBid    bool
+		wantAsk    bool
+	}{
+		{
+			name: "no_cross",
+			orders: []Order{
+				{ID: "b1", Side: Bid, Price: MustDecimal("99"), Quantity: MustDecimal("1"), Timestamp: base},
+				{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1"), Timestamp: base.Add(time.Millisecond)},
+			},
+			wantTrades: nil,
+			wantBid:    true,
+			wantAsk:    true,
+		},
+		{
+			name: "full_fill_at_maker_price",
+			orders: []Order{
+				{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("2"), Timestamp: base},
+				{ID: "b1", Side: Bid, Price: MustDecimal("101"), Quantity: MustDecimal("2"), Timestamp: base.Add(time.Millisecond)},
+			},
+			wantTrades: []Trade{
+				{MakerOrderID: "a1", TakerOrderID: "b1", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("2")},
+			},
+		},
+		{
+			name: "partial_fill_sweeps_levels",
+			orders: []Order{
+				{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1"), Timestamp: base},
+				{ID: "a2", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("1"), Timestamp: base.Add(time.Millisecond)},
+				{ID: "b1", Side: Bid, Price: MustDecimal("102"), Quantity: MustDecimal("3"), Timestamp: base.Add(2 * time.Millisecond)},
+			},
+			wantTrades: []Trade{
+				{MakerOrderID: "a1", TakerOrderID: "b1", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")},
+				{MakerOrderID: "a2", TakerOrderID: "b1", Side: Bid, Price: MustDecimal("101"), Quantity: MustDecimal("1")},
+			},
+			wantBid: true,
+		},
+		{
+			name: "time_priority_within_level",
+			orders: []Order{
+				{ID: "b1", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1"), Timestamp: base},
+				{ID: "b2", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1"), Timestamp: base.Add(time.Millisecond)},
+				{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1"), Timestamp: base.Add(2 * time.Millisecond)},
+			},
+			wantTrades: []Trade{
+				{MakerOrderID: "b1", TakerOrderID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1")},
+			},
+			wantBid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook()
+			for _, order := range tt.orders {
+				ob.InsertOrder(order)
+			}
+
+			trades := ob.MatchOrders()
+			if len(trades) != len(tt.wantTrades) {
+				t.Fatalf("MatchOrders() returned %d trades, want %d", len(trades), len(tt.wantTrades))
+			}
+
+			for i, want := range tt.wantTrades {
+				got := trades[i]
+				if got.MakerOrderID != want.MakerOrderID || got.TakerOrderID != want.TakerOrderID ||
+					got.Side != want.Side || !got.Price.Equal(want.Price) || !got.Quantity.Equal(want.Quantity) {
+					t.Errorf("trade %d = %+v, want %+v", i, got, want)
+				}
+				if got.ID != uint64(i+1) {
+					t.Errorf("trade %d has ID %d, want %d", i, got.ID, i+1)
+				}
+			}
+
+			if _, _, ok := ob.GetBestBid(); ok != tt.wantBid {
+				t.Errorf("GetBestBid() ok = %v, want %v", ok, tt.wantBid)
+			}
+			if _, _, ok := ob.GetBestAsk(); ok != tt.wantAsk {
+				t.Errorf("GetBestAsk() ok = %v, want %v", ok, tt.wantAsk)
+			}
+		})
+	}
+}
+
+func TestInsertOrderChecks(t *testing.T) {
+	ob := NewOrderBook()
+	if err := ob.InsertOrder(Order{ID: "x", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")}); err != nil {
+		t.Fatalf("InsertOrder(x) error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		order   Order
+		wantErr error
+	}{
+		{"duplicate", Order{ID: "x", Side: Bid, Price: MustDecimal("99"), Quantity: MustDecimal("1")}, ErrDuplicateOrder},
+		{"invalid", Order{ID: "y", Side: Bid, Price: MustDecimal("99")}, ErrInvalidQuantity},
+		{"IOC", Order{ID: "y", Side: Bid, Price: MustDecimal("99"), Quantity: MustDecimal("1"), TimeInForce: IOC}, ErrInvalidOrderType},
+		{"market", Order{ID: "y", Side: Bid, Type: Market, Quantity: MustDecimal("1")}, ErrInvalidOrderType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ob.InsertOrder(tt.order); err != tt.wantErr {
+				t.Errorf("InsertOrder() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The original order is still the one indexed under its ID.
+	if order, err := ob.CancelOrder("x"); err != nil || !order.Price.Equal(MustDecimal("100")) {
+		t.Errorf("CancelOrder(x) = %+v, %v, want the order at 100", order, err)
+	}
+	if !ob.Bids.Empty() {
+		t.Errorf("bids left after canceling x: %d levels", ob.Bids.Size())
+	}
+
+	ob.SetSession(SessionHalted)
+	if err := ob.InsertOrder(Order{ID: "z", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")}); err != ErrTradingHalted {
+		t.Errorf("InsertOrder() while halted error = %v, want ErrTradingHalted", err)
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	ob := NewOrderBook()
+	ob.InsertOrder(Order{ID: "b1", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+	ob.InsertOrder(Order{ID: "b2", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("2")})
+	ob.InsertOrder(Order{ID: "a1", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("3")})
+
+	tests := []struct {
+		name     string
+		orderID  string
+		wantErr  error
+		wantQty  string
+		wantBids int
+		wantAsks int
+	}{
+		{name: "cancel_first_in_level", orderID: "b1", wantQty: "1", wantBids: 1, wantAsks: 1},
+		{name: "cancel_last_in_level_drops_level", orderID: "b2", wantQty: "2", wantBids: 0, wantAsks: 1},
+		{name: "cancel_twice", orderID: "b2", wantErr: ErrOrderNotFound, wantBids: 0, wantAsks: 1},
+		{name: "cancel_unknown", orderID: "x", wantErr: ErrOrderNotFound, wantBids: 0, wantAsks: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := ob.CancelOrder(tt.orderID)
+			if err != tt.wantErr {
+				t.Fatalf("CancelOrder(%q) error = %v, want %v", tt.orderID, err, tt.wantErr)
+			}
+			if err == nil && (order.ID != tt.orderID || order.Quantity.String() != tt.wantQty) {
+				t.Errorf("CancelOrder(%q) = %+v, want quantity %s", tt.orderID, order, tt.wantQty)
+			}
+			if _, found := ob.GetOrder(tt.orderID); found {
+				t.Errorf("GetOrder(%q) still finds the order", tt.orderID)
+			}
+			if ob.Bids.Size() != tt.wantBids || ob.Asks.Size() != tt.wantAsks {
+				t.Errorf("levels = %d/%d, want %d/%d", ob.Bids.Size(), ob.Asks.Size(), tt.wantBids, tt.wantAsks)
+			}
+		})
+	}
+
+	if order, found := ob.GetOrder("a1"); !found || order.Quantity.String() != "3" {
+		t.Errorf("GetOrder(a1) = %+v, %v", order, found)
+	}
+}
+
+func TestAmendOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		price     string
+		qty       string
+		wantErr   error
+		wantFront string
+		wantPrice string
+		wantQty   string
+		wantFills int
+	}{
+		{name: "decrease_keeps_priority", price: "0", qty: "1", wantFront: "b1", wantPrice: "100", wantQty: "1"},
+		{name: "increase_loses_priority", price: "0", qty: "3", wantFront: "b2", wantPrice: "100", wantQty: "3"},
+		{name: "price_change_requeues", price: "99", qty: "0", wantFront: "b2", wantPrice: "99", wantQty: "2"},
+		{name: "crossing_price_trades", price: "101", qty: "0", wantFront: "b2", wantPrice: "101", wantQty: "1", wantFills: 1},
+		{name: "negative_quantity", price: "0", qty: "-1", wantErr: ErrInvalidQuantity, wantFront: "b1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook()
+			ob.InsertOrder(Order{ID: "b1", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("2")})
+			ob.InsertOrder(Order{ID: "b2", Side: Bid, Price: MustDecimal("100"), Quantity: MustDecimal("2")})
+			ob.InsertOrder(Order{ID: "a1", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("1")})
+
+			report, err := ob.AmendOrder("b1", MustDecimal(tt.price), MustDecimal(tt.qty))
+			if err != tt.wantErr {
+				t.Fatalf("AmendOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(report.Trades) != tt.wantFills {
+				t.Errorf("got %d trades, want %d", len(report.Trades), tt.wantFills)
+			}
+
+			queue, _ := ob.Bids.Get(MustDecimal("100"))
+			if front := queue.Front().Value.(*Order).ID; front != tt.wantFront {
+				t.Errorf("front of level 100 = %s, want %s", front, tt.wantFront)
+			}
+
+			if err != nil {
+				return
+			}
+			order, found := ob.GetOrder("b1")
+			if !found || order.Price.String() != tt.wantPrice || order.Quantity.String() != tt.wantQty {
+				t.Errorf("GetOrder(b1) = %+v, %v, want %s @ %s", order, found, tt.wantQty, tt.wantPrice)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package orderbook
+
+import (
+	"context"
+	"time"
+)
+
+type CommandType int
+
+const (
+	CmdPlace CommandType = iota
+	CmdCancel
+	CmdAmend
+	CmdExpire
+	CmdGetOrder
+	CmdSnapshot
+	CmdSetSession
+	CmdPlaceList
+)
+
+func (t CommandType) String() string {
+	switch t {
+	case CmdPlace:
+		return "Place"
+	case CmdCancel:
+		return "Cancel"
+	case CmdAmend:
+		return "Amend"
+	case CmdExpire:
+		return "Expire"
+	case CmdGetOrder:
+		return "GetOrder"
+	case CmdSnapshot:
+		return "Snapshot"
+	case CmdSetSession:
+		return "SetSession"
+	case CmdPlaceList:
+		return "PlaceList"
+	}
+	return "Unknown"
+}
+
+// Command is one request to a sequenced book. Sequence and Timestamp are
+// assigned by the sequencer for writes; reads leave Sequence zero.
+type Command struct {
+	Sequence  uint64
+	Timestamp time.Time
+	Type      CommandType
+	Order     Order        // CmdPlace
+	List      OrderList    // CmdPlaceList
+	OrderID   string       // CmdCancel, CmdAmend, CmdGetOrder
+	Price     Decimal      // CmdAmend
+	Quantity  Decimal      // CmdAmend
+	State     SessionState // CmdSetSession
+}
+
+func (c *Command) isWrite() bool {
+	switch c.Type {
+	case CmdPlace, CmdCancel, CmdAmend, CmdExpire, CmdSetSession, CmdPlaceList:
+		return true
+	}
+	return false
+}
+
+type Result struct {
+	Sequence uint64 // Sequence of the command, or of the last write for reads
+	Report   ExecutionReport
+	Reports  []ExecutionReport // CmdPlaceList
+	Order    Order             // CmdCancel, CmdGetOrder
+	Expired  []Order           // CmdExpire
+	Snapshot Snapshot
+	Auction  AuctionResult // CmdSetSession leaving PreOpen
+	Found    bool          // CmdGetOrder
+	Err      error
+}
+
+type request struct {
+	command Command
+	reply   chan Result
+}
+
+// Sequencer drives a book from a single goroutine. Commands are applied one at
+// a time in the order they are received, each write gets the next sequence
+// number, and the book is used without its lock. A book driven by a Sequencer
+// must not be used through its own methods at the same time.
+type Sequencer struct {
+	book     *OrderBook
+	requests chan request
+	done     chan struct{}
+	journal  func(Command)
+}
+
+// NewSequencer creates a sequencer for book whose command queue holds up to
+// buffer pending requests. journal, if not nil, is called on the sequencer
+// goroutine with every write command before it is applied, so that the
+// journal can later be fed to Replay.
+func NewSequencer(book *OrderBook, buffer int, journal func(Command)) *Sequencer {
+	return &Sequencer{
+		book:     book,
+		requests: make(chan request, buffer),
+		done:     make(chan struct{}),
+		journal:  journal,
+	}
+}
+
+// Run processes commands until ctx is done.
+func (s *Sequencer) Run(ctx context.Context) {
+	defer close(s.done)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-s.requests:
+			if req.command.isWrite() {
+				req.command.Sequence = s.book.sequence + 1
+				req.command.Timestamp = time.Now()
+				if s.journal != nil {
+					s.journal(req.command)
+				}
+			}
+			req.reply <- s.book.apply(req.command)
+		}
+	}
+}
+
+// Submit queues a command and waits for its result.
+func (s *Sequencer) Submit(ctx context.Context, command Command) (Result, error) {
+	req := request{
+		command: command,
+		reply:   make(chan Result, 1),
+	}
+
+	select {
+	case s.requests <- req:
+	case <-s.done:
+		return Result{}, ErrSequencerStopped
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+
+	select {
+	case result := <-req.reply:
+		return result, nil
+	case <-s.done:
+		return Result{}, ErrSequencerStopped
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+}
+
+func (s *Sequencer) PlaceOrder(ctx context.Context, order Order) (Result, error) {
+	return s.Submit(ctx, Command{Type: CmdPlace, Order: order})
+}
+
+func (s *Sequencer) PlaceOrderList(ctx context.Context, orders OrderList) (Result, error) {
+	return s.Submit(ctx, Command{Type: CmdPlaceList, List: orders})
+}
+
+func (s *Sequencer) CancelOrder(ctx context.Context, orderID string) (Result, error) {
+	return s.Submit(ctx, Command{Type: CmdCancel, OrderID: orderID})
+}
+
+func (s *Sequencer) AmendOrder(ctx context.Context, orderID string, newPrice, newQty Decimal) (Result, error) {
+	return s.Submit(ctx, Command{Type: CmdAmend, OrderID: orderID, Price: newPrice, Quantity: newQty})
+}
+
+func (s *Sequencer) ExpireOrders(ctx context.Context) (Result, error) {
+	return s.Submit(ctx, Command{Type: CmdExpire})
+}
+
+func (s *Sequencer) SetSession(ctx context.Context, state SessionState) (Result, error) {
+	return s.Submit(ctx, Command{Type: CmdSetSession, State: state})
+}
+
+func (s *Sequencer) GetOrder(ctx context.Context, orderID string) (Result, error) {
+	return s.Submit(ctx, Command{Type: CmdGetOrder, OrderID: orderID})
+}
+
+func (s *Sequencer) Snapshot(ctx context.Context) (Result, error) {
+	return s.Submit(ctx, Command{Type: CmdSnapshot})
+}
+
+// Replay applies journaled write commands to book in sequence order, as the
+// sequencer did originally. It stops at the first command whose sequence does
+// not directly follow the book's.
+func Replay(book *OrderBook, commands []Command) ([]Result, error) {
+	book.Lock()
+	defer book.Unlock()
+
+	results := make([]Result, 0, len(commands))
+	for _, command := range commands {
+		if command.Sequence != book.sequence+1 {
+			return results, ErrSequenceGap
+		}
+		results = append(results, book.apply(command))
+	}
+	return results, nil
+}
+
+// apply runs a command against the book without locking.
+func (ob *OrderBook) apply(command Command) Result {
+	if command.isWrite() {
+		ob.begin(command.Timestamp)
+	}
+
+	result := Result{}
+	switch command.Type {
+	case CmdPlace:
+		result.Report, result.Err = ob.placeOrder(command.Order)
+	case CmdPlaceList:
+		result.Reports, result.Err = ob.placeOrderList(command.List)
+	case CmdCancel:
+		result.Order, result.Err = ob.cancelOrder(command.OrderID)
+	case CmdAmend:
+		result.Report, result.Err = ob.amendOrder(command.OrderID, command.Price, command.Quantity)
+	case CmdExpire:
+		result.Expired = ob.expireOrders()
+	case CmdSetSession:
+		result.Auction, result.Err = ob.setSession(command.State)
+	case CmdGetOrder:
+		result.Order, result.Found = ob.getOrder(command.OrderID)
+	case CmdSnapshot:
+		result.Snapshot = ob.snapshot()
+	default:
+		result.Err = ErrInvalidCommand
+	}
+	result.Sequence = ob.sequence
+	return result
+}
@@ -0,0 +1,67 @@
+package orderbook
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSequencerReplay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var journal []Command
+	book := NewOrderBook()
+	sequencer := NewSequencer(book, 16, func(c Command) {
+		journal = append(journal, c)
+	})
+	go sequencer.Run(ctx)
+
+	mustSubmit := func(result Result, err error) Result {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+		return result
+	}
+
+	mustSubmit(sequencer.PlaceOrder(ctx, Order{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("2")}))
+	mustSubmit(sequencer.PlaceOrder(ctx, Order{ID: "a2", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("1")}))
+	mustSubmit(sequencer.AmendOrder(ctx, "a2", MustDecimal("100.5"), Decimal{}))
+	placed := mustSubmit(sequencer.PlaceOrder(ctx, Order{ID: "b1", Side: Bid, Price: MustDecimal("100.5"), Quantity: MustDecimal("2.5")}))
+	canceled := mustSubmit(sequencer.CancelOrder(ctx, "missing"))
+
+	if placed.Sequence != 4 || len(placed.Report.Trades) != 2 {
+		t.Errorf("place result = %+v, want sequence 4 with two trades", placed)
+	}
+	if canceled.Sequence != 5 || canceled.Err != ErrOrderNotFound {
+		t.Errorf("cancel result = %+v, want sequence 5 and ErrOrderNotFound", canceled)
+	}
+
+	query := mustSubmit(sequencer.GetOrder(ctx, "a2"))
+	if !query.Found || query.Sequence != 5 || query.Order.Quantity.String() != "0.5" {
+		t.Errorf("GetOrder result = %+v, want a2 with 0.5 left at sequence 5", query)
+	}
+
+	original := mustSubmit(sequencer.Snapshot(ctx)).Snapshot
+	cancel()
+
+	replayed := NewOrderBook()
+	results, err := Replay(replayed, journal)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if !reflect.DeepEqual(results[3].Report.Trades, placed.Report.Trades) {
+		t.Errorf("replayed trades = %+v, want %+v", results[3].Report.Trades, placed.Report.Trades)
+	}
+
+	snapshot := replayed.Snapshot()
+	snapshot.Timestamp = original.Timestamp
+	if !reflect.DeepEqual(snapshot, original) {
+		t.Errorf("replayed snapshot = %+v, want %+v", snapshot, original)
+	}
+
+	if _, err := Replay(replayed, journal[:1]); err != ErrSequenceGap {
+		t.Errorf("Replay() of old commands error = %v, want ErrSequenceGap", err)
+	}
+}
@@ -0,0 +1,231 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SessionState is the trading phase of a book.
+//
+//	Closed      cancels only
+//	PreOpen     call auction: orders rest without matching
+//	Continuous  continuous matching
+//	Halted      cancels only, nothing matches and stops stay dormant
+//	PostClose   cancels only
+type SessionState int
+
+const (
+	SessionContinuous SessionState = iota
+	SessionClosed
+	SessionPreOpen
+	SessionHalted
+	SessionPostClose
+)
+
+func (s SessionState) String() string {
+	switch s {
+	case SessionContinuous:
+		return "Continuous"
+	case SessionClosed:
+		return "Closed"
+	case SessionPreOpen:
+		return "PreOpen"
+	case SessionHalted:
+		return "Halted"
+	case SessionPostClose:
+		return "PostClose"
+	}
+	return "Unknown"
+}
+
+func (s SessionState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *SessionState) UnmarshalText(text []byte) error {
+	for state := SessionContinuous; state <= SessionPostClose; state++ {
+		if state.String() == string(text) {
+			*s = state
+			return nil
+		}
+	}
+	return ErrInvalidSessionState
+}
+
+// sessionTransitions lists the states each state may move to. Leaving PreOpen
+// always goes through Continuous, so the book is uncrossed before anything
+// else happens to it.
+var sessionTransitions = map[SessionState][]SessionState{
+	SessionClosed:     {SessionPreOpen, SessionContinuous},
+	SessionPreOpen:    {SessionContinuous},
+	SessionContinuous: {SessionPreOpen, SessionHalted, SessionPostClose},
+	SessionHalted:     {SessionPreOpen, SessionContinuous, SessionPostClose},
+	SessionPostClose:  {SessionClosed},
+}
+
+// admits returns the error for orders and amends the state does not accept.
+func (s SessionState) admits() error {
+	switch s {
+	case SessionPreOpen, SessionContinuous:
+		return nil
+	case SessionHalted:
+		return ErrTradingHalted
+	}
+	return ErrMarketClosed
+}
+
+// Session returns the current trading phase of the book.
+func (ob *OrderBook) Session() SessionState {
+	ob.RLock()
+	defer ob.RUnlock()
+
+	return ob.session
+}
+
+// SetSession moves the book to another trading phase. Moving to the current
+// phase does nothing. Leaving the pre-open auction uncrosses the book, and the
+// auction result is returned.
+func (ob *OrderBook) SetSession(state SessionState) (AuctionResult, error) {
+	ob.Lock()
+	defer ob.Unlock()
+	ob.begin(time.Now())
+
+	return ob.setSession(state)
+}
+
+func (ob *OrderBook) setSession(state SessionState) (AuctionResult, error) {
+	defer ob.repeg()
+
+	if state == ob.session {
+		return AuctionResult{}, nil
+	}
+	allowed := false
+	for _, next := range sessionTransitions[ob.session] {
+		allowed = allowed || next == state
+	}
+	if !allowed {
+		return AuctionResult{}, fmt.Errorf("%w: %s to %s", ErrInvalidTransition, ob.session, state)
+	}
+
+	ob.haltedUntil = time.Time{}
+	if ob.session == SessionPreOpen {
+		return ob.uncross(), nil
+	}
+	ob.transition(state, ReasonRequested, Decimal{})
+	return AuctionResult{}, nil
+}
+
+// transition switches the state and publishes the change.
+func (ob *OrderBook) transition(state SessionState, reason string, price Decimal) {
+	ob.session = state
+	ob.emit(Event{Type: EventSessionChanged, Session: state, Price: price, Reason: reason})
+	if state == SessionPreOpen {
+		ob.emitIndication()
+	}
+}
+
+// TimeOfDay is an offset from midnight, written as "15:04" or "15:04:05" in
+// configuration.
+type TimeOfDay time.Duration
+
+func (t TimeOfDay) String() string {
+	d := time.Duration(t)
+	s := fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	if seconds := int(d.Seconds()) % 60; seconds != 0 {
+		s += fmt.Sprintf(":%02d", seconds)
+	}
+	return s
+}
+
+// on returns the time t falls on in the day of year, month and day in loc. It
+// follows the wall clock, so 09:00 stays 09:00 on the day the clocks change.
+func (t TimeOfDay) on(year int, month time.Month, day int, loc *time.Location) time.Time {
+	d := time.Duration(t)
+	return time.Date(year, month, day, int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60, 0, loc)
+}
+
+func (t TimeOfDay) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *TimeOfDay) UnmarshalText(text []byte) error {
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if parsed, err := time.Parse(layout, string(text)); err == nil {
+			*t = TimeOfDay(parsed.Sub(parsed.Truncate(24 * time.Hour)))
+			return nil
+		}
+	}
+	return ErrInvalidTimeOfDay
+}
+
+// ScheduledTransition moves a book to State every day at At.
+type ScheduledTransition struct {
+	At    TimeOfDay    `json:"at"`
+	State SessionState `json:"state"`
+}
+
+// SessionScheduler drives a book through a daily schedule of trading phases.
+type SessionScheduler struct {
+	book     *OrderBook
+	schedule []ScheduledTransition
+	location *time.Location
+	onError  func(ScheduledTransition, error)
+}
+
+// NewSessionScheduler creates a scheduler that applies schedule in location,
+// or UTC if location is nil. onError, if not nil, receives transitions the
+// book refused.
+func NewSessionScheduler(book *OrderBook, schedule []ScheduledTransition, location *time.Location, onError func(ScheduledTransition, error)) *SessionScheduler {
+	if location == nil {
+		location = time.UTC
+	}
+	return &SessionScheduler{
+		book:     book,
+		schedule: schedule,
+		location: location,
+		onError:  onError,
+	}
+}
+
+// Run blocks until ctx is done.
+func (s *SessionScheduler) Run(ctx context.Context) {
+	if len(s.schedule) == 0 {
+		<-ctx.Done()
+		return
+	}
+
+	for {
+		transition, at := s.next(time.Now())
+		timer := time.NewTimer(time.Until(at))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if _, err := s.book.SetSession(transition.State); err != nil && s.onError != nil {
+				s.onError(transition, err)
+			}
+		}
+	}
+}
+
+// next returns the first scheduled transition after now and when it is due.
+func (s *SessionScheduler) next(now time.Time) (ScheduledTransition, time.Time) {
+	now = now.In(s.location)
+	year, month, day := now.Date()
+
+	var next ScheduledTransition
+	var due time.Time
+	for _, transition := range s.schedule {
+		at := transition.At.on(year, month, day, s.location)
+		if !at.After(now) {
+			at = transition.At.on(year, month, day+1, s.location)
+		}
+		if due.IsZero() || at.Before(due) {
+			next, due = transition, at
+		}
+	}
+	return next, due
+}
@@ -0,0 +1,121 @@
+package orderbook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSessionTransitions(t *testing.T) {
+	ob := NewOrderBook()
+
+	var changes []SessionState
+	ob.Subscribe(ListenerFunc(func(e Event) {
+		if e.Type == EventSessionChanged {
+			changes = append(changes, e.Session)
+		}
+	}))
+
+	ob.PlaceOrder(Order{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+
+	steps := []struct {
+		state    SessionState
+		wantErr  error
+		placeErr error
+	}{
+		{SessionHalted, nil, ErrTradingHalted},
+		{SessionClosed, ErrInvalidTransition, ErrTradingHalted},
+		{SessionPostClose, nil, ErrMarketClosed},
+		{SessionClosed, nil, ErrMarketClosed},
+		{SessionPreOpen, nil, nil},
+		{SessionHalted, ErrInvalidTransition, nil},
+		{SessionContinuous, nil, nil},
+	}
+
+	for i, step := range steps {
+		if _, err := ob.SetSession(step.state); !errors.Is(err, step.wantErr) {
+			t.Fatalf("step %d: SetSession(%s) error = %v, want %v", i, step.state, err, step.wantErr)
+		}
+
+		id := string(rune('b' + i))
+		_, err := ob.PlaceOrder(Order{ID: id, Side: Bid, Price: MustDecimal("99"), Quantity: MustDecimal("1")})
+		if err != step.placeErr {
+			t.Errorf("step %d: PlaceOrder() in %s error = %v, want %v", i, ob.Session(), err, step.placeErr)
+		}
+		if err == nil {
+			if _, err := ob.CancelOrder(id); err != nil {
+				t.Errorf("step %d: CancelOrder() error = %v", i, err)
+			}
+		}
+	}
+
+	want := []SessionState{SessionHalted, SessionPostClose, SessionClosed, SessionPreOpen, SessionContinuous}
+	if len(changes) != len(want) {
+		t.Fatalf("session changes = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("session changes = %v, want %v", changes, want)
+			break
+		}
+	}
+
+	if _, err := ob.CancelOrder("a1"); err != nil {
+		t.Errorf("CancelOrder(a1) error = %v", err)
+	}
+}
+
+func TestSessionScheduler(t *testing.T) {
+	var schedule []ScheduledTransition
+	for _, entry := range []struct{ at, state string }{
+		{"08:00", "PreOpen"},
+		{"09:30", "Continuous"},
+		{"16:00:30", "PostClose"},
+	} {
+		var transition ScheduledTransition
+		if err := transition.At.UnmarshalText([]byte(entry.at)); err != nil {
+			t.Fatalf("TimeOfDay.UnmarshalText(%q) error = %v", entry.at, err)
+		}
+		if err := transition.State.UnmarshalText([]byte(entry.state)); err != nil {
+			t.Fatalf("SessionState.UnmarshalText(%q) error = %v", entry.state, err)
+		}
+		schedule = append(schedule, transition)
+	}
+	if schedule[2].At.String() != "16:00:30" {
+		t.Errorf("TimeOfDay.String() = %s, want 16:00:30", schedule[2].At)
+	}
+
+	scheduler := NewSessionScheduler(NewOrderBook(), schedule, nil, nil)
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		now       time.Time
+		wantState SessionState
+		wantDue   time.Time
+	}{
+		{day.Add(7 * time.Hour), SessionPreOpen, day.Add(8 * time.Hour)},
+		{day.Add(8 * time.Hour), SessionContinuous, day.Add(9*time.Hour + 30*time.Minute)},
+		{day.Add(12 * time.Hour), SessionPostClose, day.Add(16*time.Hour + 30*time.Second)},
+		{day.Add(20 * time.Hour), SessionPreOpen, day.Add(32 * time.Hour)},
+	}
+	for _, tt := range tests {
+		transition, due := scheduler.next(tt.now)
+		if transition.State != tt.wantState || !due.Equal(tt.wantDue) {
+			t.Errorf("next(%v) = %s at %v, want %s at %v", tt.now, transition.State, due, tt.wantState, tt.wantDue)
+		}
+	}
+
+	// Berlin moves to summer time at 02:00 on 2026-03-29; 08:00 is still
+	// 08:00 on the wall clock, whether computed that day or the day before.
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	scheduler = NewSessionScheduler(NewOrderBook(), schedule, berlin, nil)
+	want := time.Date(2026, 3, 29, 8, 0, 0, 0, berlin)
+	for _, now := range []time.Time{time.Date(2026, 3, 28, 20, 0, 0, 0, berlin), time.Date(2026, 3, 29, 1, 0, 0, 0, berlin)} {
+		if _, due := scheduler.next(now); !due.Equal(want) || due.Hour() != 8 {
+			t.Errorf("next(%v) due %v, want %v", now, due, want)
+		}
+	}
+}
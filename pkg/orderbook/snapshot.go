@@ -0,0 +1,84 @@
+package orderbook
+
+import (
+	"container/list"
+	"time"
+
+	"github.com/emirpasic/gods/v2/trees/redblacktree"
+)
+
+// Snapshot is a full order-by-order (L3) copy of the book. Sequence is the
+// sequence number of the last write applied before the snapshot was taken and
+// EventSequence that of the last event published, so a consumer can apply
+// later commands or events on top of it.
+type Snapshot struct {
+	Sequence      uint64          `json:"sequence"`
+	EventSequence uint64          `json:"eventSequence"`
+	Timestamp     time.Time       `json:"timestamp"`
+	Session       SessionState    `json:"session"`
+	Bids          []LevelSnapshot `json:"bids"` // Best (highest) price first
+	Asks          []LevelSnapshot `json:"asks"` // Best (lowest) price first
+}
+
+type LevelSnapshot struct {
+	Price  Decimal         `json:"price"`
+	Orders []OrderSnapshot `json:"orders"` // Queue order
+}
+
+type OrderSnapshot struct {
+	ID        string    `json:"id"`
+	AccountID string    `json:"accountId,omitempty"`
+	Quantity  Decimal   `json:"quantity"`
+	Displayed Decimal   `json:"displayed"` // Differs from Quantity for icebergs
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Snapshot copies every resting order under the read lock.
+func (ob *OrderBook) Snapshot() Snapshot {
+	ob.RLock()
+	defer ob.RUnlock()
+
+	return ob.snapshot()
+}
+
+func (ob *OrderBook) snapshot() Snapshot {
+	return Snapshot{
+		Sequence:      ob.sequence,
+		EventSequence: ob.eventSequence,
+		Timestamp:     time.Now(),
+		Session:       ob.session,
+		Bids:          snapshotLevels(ob.Bids),
+		Asks:          snapshotLevels(ob.Asks),
+	}
+}
+
+// Sequence returns the sequence number of the last write applied to the book.
+func (ob *OrderBook) Sequence() uint64 {
+	ob.RLock()
+	defer ob.RUnlock()
+
+	return ob.sequence
+}
+
+func snapshotLevels(tree *redblacktree.Tree[Decimal, *list.List]) []LevelSnapshot {
+	levels := make([]LevelSnapshot, 0, tree.Size())
+	for iter := tree.Iterator(); iter.Next(); {
+		queue := iter.Value()
+		level := LevelSnapshot{
+			Price:  iter.Key(),
+			Orders: make([]OrderSnapshot, 0, queue.Len()),
+		}
+		for e := queue.Front(); e != nil; e = e.Next() {
+			order := e.Value.(*Order)
+			level.Orders = append(level.Orders, OrderSnapshot{
+				ID:        order.ID,
+				AccountID: order.AccountID,
+				Quantity:  order.Quantity,
+				Displayed: order.displayed(),
+				Timestamp: order.Timestamp,
+			})
+		}
+		levels = append(levels, level)
+	}
+	return levels
+}
@@ -0,0 +1,53 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	ob := NewOrderBook()
+	for _, order := range []Order{
+		{ID: "b1", AccountID: "alice", Side: Bid, Price: MustDecimal("99.5"), Quantity: MustDecimal("1")},
+		{ID: "b2", AccountID: "bob", Side: Bid, Price: MustDecimal("99.5"), Quantity: MustDecimal("2")},
+		{ID: "b3", Side: Bid, Price: MustDecimal("98"), Quantity: MustDecimal("1")},
+		{ID: "a1", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("5"), DisplayQuantity: MustDecimal("1")},
+	} {
+		if _, err := ob.PlaceOrder(order); err != nil {
+			t.Fatalf("PlaceOrder(%s) error = %v", order.ID, err)
+		}
+	}
+	if _, err := ob.CancelOrder("b3"); err != nil {
+		t.Fatalf("CancelOrder() error = %v", err)
+	}
+
+	snapshot := ob.Snapshot()
+	if snapshot.Sequence != 5 {
+		t.Errorf("Sequence = %d, want 5", snapshot.Sequence)
+	}
+	if len(snapshot.Bids) != 1 || len(snapshot.Asks) != 1 {
+		t.Fatalf("got %d/%d levels, want 1/1", len(snapshot.Bids), len(snapshot.Asks))
+	}
+
+	bids := snapshot.Bids[0].Orders
+	if len(bids) != 2 || bids[0].ID != "b1" || bids[0].AccountID != "alice" || bids[1].ID != "b2" {
+		t.Errorf("bid level = %+v, want b1 then b2", bids)
+	}
+
+	ask := snapshot.Asks[0].Orders[0]
+	if ask.Quantity.String() != "5" || ask.Displayed.String() != "1" {
+		t.Errorf("iceberg snapshot = %+v, want quantity 5 displaying 1", ask)
+	}
+
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded Snapshot
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.Sequence != snapshot.Sequence || decoded.Bids[0].Price.String() != "99.5" {
+		t.Errorf("round trip = %+v", decoded)
+	}
+}
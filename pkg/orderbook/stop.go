@@ -0,0 +1,179 @@
+package orderbook
+
+import (
+	"container/list"
+	"time"
+
+	"github.com/emirpasic/gods/v2/trees/redblacktree"
+)
+
+// stopBook holds dormant stop orders keyed by stop price. Buy stops are kept in
+// ascending order and sell stops in descending order, so in both trees the
+// first level is the one the market reaches first.
+type stopBook struct {
+	buys   *redblacktree.Tree[Decimal, *list.List]
+	sells  *redblacktree.Tree[Decimal, *list.List]
+	orders map[string]*list.Element
+
+	// Trailing stops in arrival order, so that they are re-queued
+	// deterministically when their triggers move.
+	trailing *list.List
+	trails   map[string]*list.Element
+
+	// Stops triggered by the trades of the current write, in trade order,
+	// waiting to be activated once matching is done.
+	pending []*Order
+}
+
+func newStopBook() *stopBook {
+	return &stopBook{
+		buys: redblacktree.NewWith[Decimal, *list.List](func(a, b Decimal) int {
+			return a.Cmp(b)
+		}),
+		sells: redblacktree.NewWith[Decimal, *list.List](func(a, b Decimal) int {
+			return b.Cmp(a)
+		}),
+		orders:   make(map[string]*list.Element),
+		trailing: list.New(),
+		trails:   make(map[string]*list.Element),
+	}
+}
+
+func (sb *stopBook) tree(side OrderSide) *redblacktree.Tree[Decimal, *list.List] {
+	if side == Ask {
+		return sb.sells
+	}
+	return sb.buys
+}
+
+func (sb *stopBook) add(order *Order) {
+	sb.enqueue(order)
+	if order.isTrailing() {
+		sb.trails[order.ID] = sb.trailing.PushBack(order)
+	}
+}
+
+func (sb *stopBook) enqueue(order *Order) {
+	tree := sb.tree(order.Side)
+
+	queue, found := tree.Get(order.StopPrice)
+	if !found {
+		queue = list.New()
+		tree.Put(order.StopPrice, queue)
+	}
+
+	sb.orders[order.ID] = queue.PushBack(order)
+}
+
+func (sb *stopBook) remove(e *list.Element) *Order {
+	order := e.Value.(*Order)
+	sb.dequeue(e)
+	sb.forget(order)
+	return order
+}
+
+// dequeue takes the element off its stop price level.
+func (sb *stopBook) dequeue(e *list.Element) {
+	order := e.Value.(*Order)
+	tree := sb.tree(order.Side)
+
+	queue, _ := tree.Get(order.StopPrice)
+	queue.Remove(e)
+	if queue.Len() == 0 {
+		tree.Remove(order.StopPrice)
+	}
+}
+
+func (sb *stopBook) forget(order *Order) {
+	delete(sb.orders, order.ID)
+	if e, found := sb.trails[order.ID]; found {
+		sb.trailing.Remove(e)
+		delete(sb.trails, order.ID)
+	}
+}
+
+// triggered removes and returns every stop order that a trade at last
+// activates. Buy stops come first, then sell stops; each side is ordered by
+// stop price in the direction the market moves and then by arrival.
+func (sb *stopBook) triggered(last Decimal) []*Order {
+	var orders []*Order
+	for _, side := range []OrderSide{Bid, Ask} {
+		tree := sb.tree(side)
+		for {
+			iter := tree.Iterator()
+			if !iter.Next() || !reached(side, iter.Key(), last) {
+				break
+			}
+			for e := iter.Value().Front(); e != nil; e = e.Next() {
+				order := e.Value.(*Order)
+				sb.forget(order)
+				orders = append(orders, order)
+			}
+			tree.Remove(iter.Key())
+		}
+	}
+	return orders
+}
+
+// reached reports whether a stop on side with the given stop price is
+// triggered by a trade at last.
+func reached(side OrderSide, stop, last Decimal) bool {
+	if side == Bid {
+		return last.Cmp(stop) >= 0
+	}
+	return last.Cmp(stop) <= 0
+}
+
+// fire takes the stops a trade at price triggers off the book, to be
+// activated once the write is done matching. Each trade is checked, so a sweep
+// that passes a stop price and moves on still triggers it.
+func (ob *OrderBook) fire(price Decimal) {
+	if ob.session == SessionContinuous {
+		ob.stops.pending = append(ob.stops.pending, ob.stops.triggered(price)...)
+	}
+}
+
+// activateStops converts every stop order triggered by a trade, or by the last
+// trade price, into a market or limit order and executes it. Trades from
+// activated orders trigger further stops, so the cascade repeats until no stop
+// triggers. Stops are only activated during continuous trading.
+func (ob *OrderBook) activateStops() []ExecutionReport {
+	var reports []ExecutionReport
+	for ob.session == SessionContinuous {
+		orders := ob.stops.pending
+		ob.stops.pending = nil
+		if !ob.lastPrice.IsZero() {
+			orders = append(orders, ob.stops.triggered(ob.lastPrice)...)
+		}
+		if len(orders) == 0 {
+			break
+		}
+
+		for _, order := range orders {
+			if order.ListID != "" && !ob.activateMember(order) {
+				continue
+			}
+			order.activate(ob.now)
+
+			limit, err := ob.admit(order)
+			if err != nil {
+				report, _ := ob.reject(order, err)
+				reports = append(reports, report)
+				continue
+			}
+			reports = append(reports, ob.execute(order, limit))
+		}
+	}
+	return reports
+}
+
+// activate turns a triggered stop into the order it stands for. The order joins
+// the book's time priority at activation.
+func (o *Order) activate(now time.Time) {
+	if o.Type == Stop {
+		o.Type = Market
+	} else {
+		o.Type = Limit
+	}
+	o.Timestamp = now
+}
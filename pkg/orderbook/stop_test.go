@@ -0,0 +1,98 @@
+package orderbook
+
+import "testing"
+
+func TestStopOrders(t *testing.T) {
+	ob := NewOrderBook()
+
+	place := func(order Order) ExecutionReport {
+		t.Helper()
+		report, err := ob.PlaceOrder(order)
+		if err != nil {
+			t.Fatalf("PlaceOrder(%s) error = %v", order.ID, err)
+		}
+		return report
+	}
+
+	for _, price := range []string{"100", "101", "102", "103"} {
+		place(Order{ID: "a" + price, Side: Ask, Price: MustDecimal(price), Quantity: MustDecimal("1")})
+	}
+
+	// Dormant until the market trades at or above the stop price.
+	report := place(Order{ID: "s1", Side: Bid, Type: Stop, StopPrice: MustDecimal("101"), Quantity: MustDecimal("1")})
+	if report.Status != StatusNew || len(report.Triggered) != 0 {
+		t.Fatalf("stop report = %+v, want dormant", report)
+	}
+	place(Order{ID: "s2", Side: Bid, Type: StopLimit, StopPrice: MustDecimal("102"), Price: MustDecimal("103"), Quantity: MustDecimal("1")})
+	place(Order{ID: "s3", Side: Bid, Type: Stop, StopPrice: MustDecimal("101"), Quantity: MustDecimal("1")})
+
+	if _, found := ob.GetOrder("s1"); !found {
+		t.Fatalf("GetOrder(s1) should find the dormant stop")
+	}
+
+	// Taking a100 then a101 moves the last price to 101 and triggers s1 and s3
+	// in arrival order; their fills move it to 103 and cascade into s2.
+	report = place(Order{ID: "b1", Side: Bid, Price: MustDecimal("101"), Quantity: MustDecimal("2")})
+
+	wantOrder := []string{"s1", "s3", "s2"}
+	if len(report.Triggered) != len(wantOrder) {
+		t.Fatalf("triggered %d stops, want %d", len(report.Triggered), len(wantOrder))
+	}
+	for i, id := range wantOrder {
+		got := report.Triggered[i]
+		if got.OrderID != id {
+			t.Errorf("triggered[%d] = %s, want %s", i, got.OrderID, id)
+		}
+	}
+
+	if got := report.Triggered[2]; got.Status != StatusNew || got.RemainingQuantity.String() != "1" {
+		t.Errorf("s2 = %+v, want resting limit with nothing left to take", got)
+	}
+	if order, found := ob.GetOrder("s2"); !found || order.Type != Limit || !order.Price.Equal(MustDecimal("103")) {
+		t.Errorf("GetOrder(s2) = %+v, %v, want resting limit at 103", order, found)
+	}
+	if _, _, ok := ob.GetBestAsk(); ok {
+		t.Errorf("all asks should have been taken")
+	}
+}
+
+func TestCancelStopOrder(t *testing.T) {
+	ob := NewOrderBook()
+	if _, err := ob.PlaceOrder(Order{ID: "s1", Side: Ask, Type: Stop, StopPrice: MustDecimal("90"), Quantity: MustDecimal("1")}); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+
+	if _, err := ob.CancelOrder("s1"); err != nil {
+		t.Fatalf("CancelOrder() error = %v", err)
+	}
+
+	ob.InsertOrder(Order{ID: "b1", Side: Bid, Price: MustDecimal("80"), Quantity: MustDecimal("1")})
+	report, err := ob.PlaceOrder(Order{ID: "a1", Side: Ask, Price: MustDecimal("80"), Quantity: MustDecimal("1")})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	if len(report.Triggered) != 0 {
+		t.Errorf("canceled stop was triggered")
+	}
+}
+
+func TestStopTriggeredMidSweep(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceOrder(Order{ID: "a0", Side: Ask, Price: MustDecimal("102"), Quantity: MustDecimal("1")})
+	ob.PlaceOrder(Order{ID: "b0", Side: Bid, Price: MustDecimal("102"), Quantity: MustDecimal("1")})
+	ob.PlaceOrder(Order{ID: "bid", Side: Bid, Price: MustDecimal("90"), Quantity: MustDecimal("1")})
+	if _, err := ob.PlaceOrder(Order{ID: "s1", Side: Ask, Type: Stop, StopPrice: MustDecimal("101"), Quantity: MustDecimal("1")}); err != nil {
+		t.Fatalf("PlaceOrder(s1) error = %v", err)
+	}
+
+	// The sweep trades at 100, below the stop, before ending at 105.
+	ob.PlaceOrder(Order{ID: "a1", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("1")})
+	ob.PlaceOrder(Order{ID: "a2", Side: Ask, Price: MustDecimal("105"), Quantity: MustDecimal("1")})
+	report, err := ob.PlaceOrder(Order{ID: "b1", Side: Bid, Price: MustDecimal("105"), Quantity: MustDecimal("2")})
+	if err != nil || len(report.Trades) != 2 {
+		t.Fatalf("PlaceOrder(b1) = %+v, %v, want two trades", report, err)
+	}
+	if len(report.Triggered) != 1 || report.Triggered[0].OrderID != "s1" || report.Triggered[0].Status != StatusFilled {
+		t.Errorf("triggered = %+v, want s1 filled", report.Triggered)
+	}
+}
@@ -0,0 +1,92 @@
+package orderbook
+
+// SelfTradePrevention decides what happens when an incoming order would trade
+// against a resting order of the same account.
+type SelfTradePrevention int
+
+const (
+	STPNone               SelfTradePrevention = iota // Allow the self-trade
+	STPCancelNewest                                  // Cancel the incoming order's remainder
+	STPCancelOldest                                  // Cancel the resting order and keep matching
+	STPCancelBoth                                    // Cancel both orders
+	STPDecrementAndCancel                            // Reduce both by the smaller size, canceling the smaller order
+)
+
+func (m SelfTradePrevention) String() string {
+	switch m {
+	case STPNone:
+		return "None"
+	case STPCancelNewest:
+		return "CancelNewest"
+	case STPCancelOldest:
+		return "CancelOldest"
+	case STPCancelBoth:
+		return "CancelBoth"
+	case STPDecrementAndCancel:
+		return "DecrementAndCancel"
+	}
+	return "Unknown"
+}
+
+// WithSelfTradePrevention sets the self-trade prevention mode of the book.
+// Orders without an AccountID are never considered self-trades.
+func WithSelfTradePrevention(mode SelfTradePrevention) Option {
+	return func(ob *OrderBook) {
+		ob.stp = mode
+	}
+}
+
+// SelfTradeEvent tells an account that quantity was taken off one of its
+// orders to prevent it from trading with CounterOrderID.
+type SelfTradeEvent struct {
+	OrderID        string
+	AccountID      string
+	CounterOrderID string
+	Mode           SelfTradePrevention
+	Quantity       Decimal // Quantity canceled or decremented
+	Canceled       bool    // Whether the order has nothing left
+}
+
+func (ob *OrderBook) isSelfTrade(maker, taker *Order) bool {
+	return ob.stp != STPNone && taker.AccountID != "" && maker.AccountID == taker.AccountID
+}
+
+// preventSelfTrade applies the book's mode to a pair of orders of the same
+// account and reports whether the taker has nothing left to match.
+func (ob *OrderBook) preventSelfTrade(maker, taker *Order) ([]SelfTradeEvent, bool) {
+	var makerQty, takerQty Decimal
+
+	switch ob.stp {
+	case STPCancelNewest:
+		takerQty = taker.Quantity
+	case STPCancelOldest:
+		makerQty = maker.Quantity
+	case STPCancelBoth:
+		makerQty, takerQty = maker.Quantity, taker.Quantity
+	case STPDecrementAndCancel:
+		qty := minDecimal(maker.Quantity, taker.Quantity)
+		makerQty, takerQty = qty, qty
+	}
+
+	var events []SelfTradeEvent
+	if makerQty.IsPositive() {
+		ob.reduce(maker, makerQty, ReasonSelfTrade)
+		events = append(events, newSelfTradeEvent(maker, taker, ob.stp, makerQty))
+	}
+	if takerQty.IsPositive() {
+		ob.reduce(taker, takerQty, ReasonSelfTrade)
+		events = append(events, newSelfTradeEvent(taker, maker, ob.stp, takerQty))
+	}
+	return events, taker.Quantity.IsZero()
+}
+
+func newSelfTradeEvent(order, counter *Order, mode SelfTradePrevention, qty Decimal) SelfTradeEvent {
+	return SelfTradeEvent{
+		OrderID:        order.ID,
+		AccountID:      order.AccountID,
+		CounterOrderID: counter.ID,
+		Mode:           mode,
+		Quantity:       qty,
+		Canceled:       order.Quantity.IsZero(),
+	}
+}
@@ -0,0 +1,99 @@
+package orderbook
+
+import "testing"
+
+func TestSelfTradePrevention(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           SelfTradePrevention
+		wantStatus     OrderStatus
+		wantFilled     string
+		wantEvents     int
+		wantOwnResting string
+		wantTakerRests bool
+	}{
+		{name: "none", mode: STPNone, wantStatus: StatusPartiallyFilled, wantFilled: "3", wantTakerRests: true},
+		{name: "cancel_newest", mode: STPCancelNewest, wantStatus: StatusCanceled, wantFilled: "0", wantEvents: 1, wantOwnResting: "2"},
+		{name: "cancel_oldest", mode: STPCancelOldest, wantStatus: StatusPartiallyFilled, wantFilled: "1", wantEvents: 1, wantTakerRests: true},
+		{name: "cancel_both", mode: STPCancelBoth, wantStatus: StatusCanceled, wantFilled: "0", wantEvents: 2},
+		{name: "decrement_and_cancel", mode: STPDecrementAndCancel, wantStatus: StatusPartiallyFilled, wantFilled: "1", wantEvents: 2, wantTakerRests: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook(WithSelfTradePrevention(tt.mode))
+			ob.InsertOrder(Order{ID: "own", AccountID: "alice", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("2")})
+			ob.InsertOrder(Order{ID: "other", AccountID: "bob", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("1")})
+
+			report, err := ob.PlaceOrder(Order{ID: "taker", AccountID: "alice", Side: Bid, Price: MustDecimal("101"), Quantity: MustDecimal("4")})
+			if err != nil {
+				t.Fatalf("PlaceOrder() error = %v", err)
+			}
+
+			if report.Status != tt.wantStatus || report.FilledQuantity.String() != tt.wantFilled {
+				t.Errorf("status/filled = %v/%s, want %v/%s", report.Status, report.FilledQuantity, tt.wantStatus, tt.wantFilled)
+			}
+			if len(report.SelfTradePrevented) != tt.wantEvents {
+				t.Errorf("got %d STP events, want %d: %+v", len(report.SelfTradePrevented), tt.wantEvents, report.SelfTradePrevented)
+			}
+			for _, event := range report.SelfTradePrevented {
+				if event.AccountID != "alice" {
+					t.Errorf("STP event reported to %q, want alice", event.AccountID)
+				}
+			}
+			for _, trade := range report.Trades {
+				if trade.MakerOrderID == "own" && tt.mode != STPNone {
+					t.Errorf("self-trade happened: %+v", trade)
+				}
+			}
+
+			own, found := ob.GetOrder("own")
+			if (tt.wantOwnResting != "") != found || (found && own.Quantity.String() != tt.wantOwnResting) {
+				t.Errorf("GetOrder(own) = %+v, %v, want resting %q", own, found, tt.wantOwnResting)
+			}
+			if _, found := ob.GetOrder("taker"); found != tt.wantTakerRests {
+				t.Errorf("taker rests = %v, want %v", found, tt.wantTakerRests)
+			}
+		})
+	}
+}
+
+func TestSelfTradePreventionFillOrKill(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       SelfTradePrevention
+		wantErr    error
+		wantStatus OrderStatus
+		wantFilled string
+	}{
+		{name: "none", mode: STPNone, wantStatus: StatusFilled, wantFilled: "10"},
+		{name: "cancel_newest", mode: STPCancelNewest, wantErr: ErrFillOrKill, wantStatus: StatusRejected, wantFilled: "0"},
+		{name: "cancel_oldest", mode: STPCancelOldest, wantStatus: StatusFilled, wantFilled: "10"},
+		{name: "cancel_both", mode: STPCancelBoth, wantErr: ErrFillOrKill, wantStatus: StatusRejected, wantFilled: "0"},
+		// 3 of the 10 are decremented against the own order, so the rest
+		// fills in full but the order as a whole is not filled.
+		{name: "decrement_and_cancel", mode: STPDecrementAndCancel, wantStatus: StatusCanceled, wantFilled: "7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook(WithSelfTradePrevention(tt.mode))
+			ob.InsertOrder(Order{ID: "x1", AccountID: "bob", Side: Ask, Price: MustDecimal("100"), Quantity: MustDecimal("5")})
+			ob.InsertOrder(Order{ID: "own", AccountID: "alice", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("3")})
+			ob.InsertOrder(Order{ID: "x2", AccountID: "bob", Side: Ask, Price: MustDecimal("101"), Quantity: MustDecimal("5")})
+
+			report, err := ob.PlaceOrder(Order{ID: "taker", AccountID: "alice", Side: Bid, Price: MustDecimal("101"), Quantity: MustDecimal("10"), TimeInForce: FOK})
+			if err != tt.wantErr {
+				t.Fatalf("PlaceOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			if report.Status != tt.wantStatus || report.FilledQuantity.String() != tt.wantFilled {
+				t.Errorf("status/filled = %v/%s, want %v/%s", report.Status, report.FilledQuantity, tt.wantStatus, tt.wantFilled)
+			}
+			if tt.wantErr != nil {
+				if order, found := ob.GetOrder("x1"); !found || !order.Quantity.Equal(MustDecimal("5")) {
+					t.Errorf("GetOrder(x1) = %+v, %v, want the book untouched", order, found)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package orderbook
+
+import "time"
+
+type Trade struct {
+	ID           uint64
+	MakerOrderID string
+	TakerOrderID string
+	Side         OrderSide // Aggressor side, i.e. the side of the taker
+	Price        Decimal
+	Quantity     Decimal
+	Timestamp    time.Time
+}
+
+func (ob *OrderBook) newTrade(maker, taker *Order, price, quantity Decimal) Trade {
+	ob.tradeID++
+	ob.lastPrice = price
+	ob.recordTrade(price)
+	ob.trail(price)
+	ob.fire(price)
+	return Trade{
+		ID:           ob.tradeID,
+		MakerOrderID: maker.ID,
+		TakerOrderID: taker.ID,
+		Side:         taker.Side,
+		Price:        price,
+		Quantity:     quantity,
+		Timestamp:    ob.now,
+	}
+}
@@ -0,0 +1,68 @@
+package orderbook
+
+// trail moves the triggers of dormant trailing stops after a trade at price.
+// Moved stops join the back of their new stop price level.
+func (ob *OrderBook) trail(price Decimal) {
+	for e := ob.stops.trailing.Front(); e != nil; e = e.Next() {
+		order := e.Value.(*Order)
+		stop, moved := trailingStop(order, price, ob.tickSize)
+		if !moved {
+			continue
+		}
+
+		ob.stops.dequeue(ob.stops.orders[order.ID])
+		order.moveStop(stop)
+		ob.stops.enqueue(order)
+	}
+}
+
+// trailStop sets the initial trigger of a trailing stop from the last trade
+// price, if that is better than the one it was placed with.
+func (ob *OrderBook) trailStop(order *Order, price Decimal) {
+	if stop, moved := trailingStop(order, price, ob.tickSize); moved {
+		order.moveStop(stop)
+	}
+}
+
+// trailingStop returns the trigger a trade at price implies for a trailing
+// stop, and whether it improves on the current one. A trigger only ever moves
+// in the order's favor: up for sells, down for buys. It is rounded to the tick
+// away from the market, as a percentage offset rarely lands on it.
+func trailingStop(order *Order, price, tick Decimal) (Decimal, bool) {
+	if price.IsZero() {
+		return Decimal{}, false
+	}
+
+	offset := order.TrailingAmount
+	if order.TrailingPercent.IsPositive() {
+		var err error
+		if offset, err = price.Mul(order.TrailingPercent); err != nil {
+			return Decimal{}, false
+		}
+	}
+
+	stop := price.Sub(offset)
+	if order.Side == Bid {
+		stop = price.Add(offset)
+	}
+	stop = roundToTick(stop, tick, order.Side == Bid)
+	if !stop.IsPositive() {
+		return Decimal{}, false
+	}
+	if order.StopPrice.IsZero() {
+		return stop, true
+	}
+	if order.Side == Bid {
+		return stop, stop.LessThan(order.StopPrice)
+	}
+	return stop, stop.GreaterThan(order.StopPrice)
+}
+
+// moveStop sets a new trigger. A stop limit order keeps the distance between
+// its trigger and its limit price.
+func (o *Order) moveStop(stop Decimal) {
+	if o.Type == StopLimit && !o.StopPrice.IsZero() {
+		o.Price = o.Price.Add(stop.Sub(o.StopPrice))
+	}
+	o.StopPrice = stop
+}
@@ -0,0 +1,129 @@
+package orderbook
+
+import "testing"
+
+func TestTrailingStop(t *testing.T) {
+	tests := []struct {
+		name      string
+		stop      Order
+		trades    []string // Prices traded after placement, the last one triggers
+		wantStops []string // StopPrice after placement and after each non-triggering trade
+		wantPrice string   // Limit price before triggering, for stop limits
+	}{
+		{
+			name:      "sell by amount",
+			stop:      Order{Side: Ask, Type: Stop, TrailingAmount: MustDecimal("2")},
+			trades:    []string{"103", "102", "101"},
+			wantStops: []string{"98", "101", "101"},
+		},
+		{
+			name:      "buy by percent",
+			stop:      Order{Side: Bid, Type: Stop, TrailingPercent: MustDecimal("0.1")},
+			trades:    []string{"90", "95", "99"},
+			wantStops: []string{"110", "99", "99"},
+		},
+		{
+			name:      "buy stop limit",
+			stop:      Order{Side: Bid, Type: StopLimit, StopPrice: MustDecimal("105"), Price: MustDecimal("107"), TrailingAmount: MustDecimal("5")},
+			trades:    []string{"98", "103"},
+			wantStops: []string{"105", "103"},
+			wantPrice: "105",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook()
+			trade := func(price string) ExecutionReport {
+				t.Helper()
+				ob.PlaceOrder(Order{ID: "m" + price, Side: Ask, Price: MustDecimal(price), Quantity: MustDecimal("1")})
+				report, err := ob.PlaceOrder(Order{ID: "t" + price, Side: Bid, Price: MustDecimal(price), Quantity: MustDecimal("1")})
+				if err != nil || len(report.Trades) != 1 {
+					t.Fatalf("trade at %s: %+v, %v", price, report, err)
+				}
+				return report
+			}
+			trade("100")
+
+			// Liquidity for the triggered order.
+			ob.PlaceOrder(Order{ID: "bid", Side: Bid, Price: MustDecimal("50"), Quantity: MustDecimal("5")})
+			ob.PlaceOrder(Order{ID: "ask", Side: Ask, Price: MustDecimal("150"), Quantity: MustDecimal("5")})
+
+			tt.stop.ID = "s1"
+			tt.stop.Quantity = MustDecimal("1")
+			if _, err := ob.PlaceOrder(tt.stop); err != nil {
+				t.Fatalf("PlaceOrder(s1) error = %v", err)
+			}
+
+			var report ExecutionReport
+			for i, price := range tt.trades {
+				order, found := ob.GetOrder("s1")
+				if !found || !order.StopPrice.Equal(MustDecimal(tt.wantStops[i])) {
+					t.Fatalf("before trade at %s: GetOrder(s1) = %+v, %v, want stop %s", price, order, found, tt.wantStops[i])
+				}
+				if tt.wantPrice != "" && i == len(tt.trades)-1 && !order.Price.Equal(MustDecimal(tt.wantPrice)) {
+					t.Errorf("limit price = %s, want %s", order.Price, tt.wantPrice)
+				}
+				report = trade(price)
+			}
+
+			if len(report.Triggered) != 1 || report.Triggered[0].OrderID != "s1" {
+				t.Errorf("last trade triggered %+v, want s1", report.Triggered)
+			}
+			if order, found := ob.GetOrder("s1"); found && order.isStop() {
+				t.Errorf("s1 still dormant: %+v", order)
+			}
+		})
+	}
+}
+
+func TestTrailingStopValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		order Order
+		want  error
+	}{
+		{"amount and percent", Order{Type: Stop, TrailingAmount: MustDecimal("1"), TrailingPercent: MustDecimal("0.01")}, ErrInvalidTrailing},
+		{"percent of 100%", Order{Type: Stop, TrailingPercent: MustDecimal("1")}, ErrInvalidTrailing},
+		{"not a stop", Order{Type: Limit, Price: MustDecimal("100"), TrailingAmount: MustDecimal("1")}, ErrInvalidTrailing},
+		{"no trade to trail", Order{Type: Stop, TrailingAmount: MustDecimal("1")}, ErrInvalidStopPrice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.order.ID = "s1"
+			tt.order.Quantity = MustDecimal("1")
+			if _, err := NewOrderBook().PlaceOrder(tt.order); err != tt.want {
+				t.Errorf("PlaceOrder() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrailingStopTick(t *testing.T) {
+	ob := NewOrderBook(WithTickSize(MustDecimal("0.5")))
+	trade := func(price string) {
+		t.Helper()
+		ob.PlaceOrder(Order{ID: "m" + price, Side: Ask, Price: MustDecimal(price), Quantity: MustDecimal("1")})
+		if report, err := ob.PlaceOrder(Order{ID: "t" + price, Side: Bid, Price: MustDecimal(price), Quantity: MustDecimal("1")}); err != nil || len(report.Trades) != 1 {
+			t.Fatalf("trade at %s: %+v, %v", price, report, err)
+		}
+	}
+	trade("100")
+
+	// 3.3% below 100 is 96.7, rounded down to 96.5; the limit keeps its 0.5
+	// distance.
+	stop := Order{ID: "s1", Side: Ask, Type: StopLimit, StopPrice: MustDecimal("90"), Price: MustDecimal("89.5"), TrailingPercent: MustDecimal("0.033"), Quantity: MustDecimal("1")}
+	if _, err := ob.PlaceOrder(stop); err != nil {
+		t.Fatalf("PlaceOrder(s1) error = %v", err)
+	}
+	if order, _ := ob.GetOrder("s1"); !order.StopPrice.Equal(MustDecimal("96.5")) || !order.Price.Equal(MustDecimal("96")) {
+		t.Errorf("on placement stop = %s, price = %s, want 96.5 and 96", order.StopPrice, order.Price)
+	}
+
+	// 3.3% below 101 is 97.667, rounded down to 97.5.
+	trade("101")
+	if order, _ := ob.GetOrder("s1"); !order.StopPrice.Equal(MustDecimal("97.5")) || !order.Price.Equal(MustDecimal("97")) {
+		t.Errorf("after 101 stop = %s, price = %s, want 97.5 and 97", order.StopPrice, order.Price)
+	}
+}